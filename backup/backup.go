@@ -0,0 +1,339 @@
+// backup/backup.go
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"listarr-backend/database"
+	"listarr-backend/utils"
+	"listarr-backend/version"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FormatVersion is the layout of the archives written by this build. Restore
+// refuses archives of any other format.
+const FormatVersion = 1
+
+// ContentType is the media type of an archive
+const ContentType = "application/gzip"
+
+// Archive entries. Tables are stored as tables/<name>.json, each a JSON array
+// of rows keyed by column name.
+const (
+	manifestEntry = "manifest.json"
+	configEntry   = "config/app.config.json"
+	tablesDir     = "tables/"
+)
+
+// lock runs one backup or restore at a time
+var lock sync.Mutex
+
+// settingsTable holds the settings sections when settings.backend is
+// database; its secrets are stripped like those of app.config.json
+const settingsTable = "settings"
+
+// idempotencyTable caches the responses of retried requests, which may hold
+// decrypted secrets such as those of POST /config/reset. It is left out of
+// archives and cleared on restore, as the responses describe the data being
+// replaced.
+const idempotencyTable = "idempotency_keys"
+
+// Manifest describes an archive
+type Manifest struct {
+	Format int `json:"format"`
+	// AppVersion is the version of Listarr that made the archive
+	AppVersion string `json:"appVersion"`
+	// SchemaVersion is the last migration applied to the database, which
+	// the rows of the tables match
+	SchemaVersion uint      `json:"schemaVersion"`
+	Driver        string    `json:"driver"`
+	CreatedAt     time.Time `json:"createdAt"`
+	// Secrets reports whether passwords, tokens and API keys are included
+	Secrets bool `json:"secrets"`
+	// Tables counts the rows of each table
+	Tables map[string]int `json:"tables"`
+}
+
+// Options select what goes into an archive
+type Options struct {
+	// ExcludeSecrets leaves secrets out of the configuration and settings,
+	// for archives shared or stored where credentials must not be
+	ExcludeSecrets bool
+}
+
+// Archive is the content of a backup read back for restoring
+type Archive struct {
+	Manifest Manifest
+	Tables   map[string][]map[string]interface{}
+	Config   []byte
+}
+
+// Create writes an archive of every table of db but idempotencyTable and
+// app.config.json to w. The tables are read in one transaction so they are
+// consistent with each other.
+func Create(ctx context.Context, db *gorm.DB, w io.Writer, opts Options) (Manifest, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	manifest := Manifest{
+		Format:     FormatVersion,
+		AppVersion: version.Version,
+		Driver:     db.Dialector.Name(),
+		CreatedAt:  time.Now().UTC(),
+		Secrets:    !opts.ExcludeSecrets,
+		Tables:     map[string]int{},
+	}
+
+	migrator, err := database.Migrator(db)
+	if err != nil {
+		return manifest, err
+	}
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return manifest, err
+	}
+	if status.Failed != nil {
+		return manifest, fmt.Errorf("database has a failed migration %d (%s)", status.Failed.Version, status.Failed.Name)
+	}
+	manifest.SchemaVersion = status.Current
+
+	// Large tables may take longer to read than db.timeout
+	ctx = database.WithoutQueryTimeout(ctx)
+	tables := map[string][]byte{}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		names, err := tableNames(tx)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if name == idempotencyTable {
+				continue
+			}
+			var rows []map[string]interface{}
+			if err := tx.Table(name).Find(&rows).Error; err != nil {
+				return fmt.Errorf("error reading table %s: %w", name, err)
+			}
+			if name == settingsTable && opts.ExcludeSecrets {
+				if err := stripSettingsSecrets(rows); err != nil {
+					return err
+				}
+			}
+
+			data, err := json.Marshal(rows)
+			if err != nil {
+				return fmt.Errorf("error encoding table %s: %w", name, err)
+			}
+			tables[name] = data
+			manifest.Tables[name] = len(rows)
+		}
+		return nil
+	}, snapshotOptions(db))
+	if err != nil {
+		return manifest, err
+	}
+
+	config, err := utils.BackupConfigFile(opts.ExcludeSecrets)
+	if err != nil {
+		return manifest, err
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+
+	// The manifest comes first so a reader knows the format before the rest
+	entries := map[string][]byte{configEntry: config}
+	for name, data := range tables {
+		entries[tablesDir+name+".json"] = data
+	}
+	if err := writeArchive(w, manifestData, entries, manifest.CreatedAt); err != nil {
+		return manifest, fmt.Errorf("error writing archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// writeArchive writes a gzipped tar of the manifest followed by entries in
+// name order
+func writeArchive(w io.Writer, manifest []byte, entries map[string][]byte, modTime time.Time) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	write := func(name string, data []byte) error {
+		header := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: modTime}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := write(manifestEntry, manifest); err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(entries) {
+		if err := write(name, entries[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Read reads an archive written by Create, checking that it is complete and
+// of a format this build understands
+func Read(r io.Reader) (*Archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+
+	archive := &Archive{Tables: map[string][]map[string]interface{}{}}
+	var manifestData []byte
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading archive: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("error reading archive: %w", err)
+		}
+
+		switch name := path.Clean(header.Name); {
+		case name == manifestEntry:
+			manifestData = data
+		case name == configEntry:
+			archive.Config = data
+		case strings.HasPrefix(name, tablesDir) && path.Ext(name) == ".json":
+			// Numbers are kept exact until the column types are known
+			var rows []map[string]interface{}
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			if err := decoder.Decode(&rows); err != nil {
+				return nil, fmt.Errorf("error reading %s: %w", name, err)
+			}
+			archive.Tables[strings.TrimSuffix(path.Base(name), ".json")] = rows
+		}
+	}
+
+	if manifestData == nil {
+		return nil, errors.New("not a backup archive: manifest.json is missing")
+	}
+	if err := json.Unmarshal(manifestData, &archive.Manifest); err != nil {
+		return nil, fmt.Errorf("error reading manifest.json: %w", err)
+	}
+	if archive.Manifest.Format != FormatVersion {
+		return nil, fmt.Errorf("backup format %d is not supported, this build reads format %d", archive.Manifest.Format, FormatVersion)
+	}
+	if archive.Config == nil {
+		return nil, errors.New("backup is incomplete: " + configEntry + " is missing")
+	}
+	for name, count := range archive.Manifest.Tables {
+		if rows, ok := archive.Tables[name]; !ok || len(rows) != count {
+			return nil, fmt.Errorf("backup is incomplete: table %s should have %d rows", name, count)
+		}
+	}
+	for name := range archive.Tables {
+		if _, ok := archive.Manifest.Tables[name]; !ok {
+			return nil, fmt.Errorf("backup holds table %s missing from its manifest", name)
+		}
+	}
+	return archive, nil
+}
+
+// FileName names an archive made at t
+func FileName(t time.Time) string {
+	return "listarr_backup_" + t.UTC().Format("20060102_150405") + ".tar.gz"
+}
+
+// tableNames lists the tables holding data, leaving out the migration
+// records, which describe the schema rather than its content
+func tableNames(db *gorm.DB) ([]string, error) {
+	names, err := db.Migrator().GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("error listing tables: %w", err)
+	}
+
+	var tables []string
+	for _, name := range names {
+		if name != "schema_migrations" && !strings.HasPrefix(name, "sqlite_") {
+			tables = append(tables, name)
+		}
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// snapshotOptions reads every table from the same snapshot on Postgres.
+// SQLite transactions are serializable already.
+func snapshotOptions(db *gorm.DB) *sql.TxOptions {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+}
+
+// stripSettingsSecrets removes the secrets from rows of the settings table,
+// each holding one configuration section as JSON
+func stripSettingsSecrets(rows []map[string]interface{}) error {
+	for _, row := range rows {
+		err := updateSection(row, func(section map[string]interface{}) {
+			utils.StripSecrets(section)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateSection lets fn change the section of a settings row as a nested
+// config map
+func updateSection(row map[string]interface{}, fn func(section map[string]interface{})) error {
+	name, _ := row["section"].(string)
+	value, _ := row["value"].(string)
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return fmt.Errorf("error parsing %s settings: %w", name, err)
+	}
+	section := map[string]interface{}{name: decoded}
+	fn(section)
+
+	data, err := json.Marshal(section[name])
+	if err != nil {
+		return fmt.Errorf("error encoding %s settings: %w", name, err)
+	}
+	row["value"] = string(data)
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,309 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"listarr-backend/database"
+	"listarr-backend/models"
+	"listarr-backend/utils"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setup runs the test from an empty directory holding a default
+// app.config.json and returns a migrated SQLite database
+func setup(t *testing.T) *gorm.DB {
+	t.Helper()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+	require.NoError(t, utils.InitConfig())
+	return openDB(t, true)
+}
+
+func openDB(t *testing.T, migrate bool) *gorm.DB {
+	t.Helper()
+	cfg := &models.Configuration{}
+	cfg.Db.Driver = "sqlite"
+	cfg.Db.Path = filepath.Join(t.TempDir(), "listarr.db")
+	cfg.Db.MaxConns = 1
+	cfg.Db.Timeout = 5
+
+	db, err := database.Open(cfg)
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if migrate {
+		cfg.Db.MigrateOnStart = true
+		require.NoError(t, database.Prepare(context.Background(), db, cfg))
+	}
+	return db
+}
+
+func seed(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	require.NoError(t, db.Create(&models.User{Name: "Alice", Email: "alice@example.com", Password: "password123"}).Error)
+	require.NoError(t, db.Create(&models.User{Name: "Bob", Email: "bob@example.com", Password: "password123"}).Error)
+	require.NoError(t, db.Create(&models.Setting{Section: "integrations", Value: `{"plex":{"host":"plex.local","token":"plex-token"}}`}).Error)
+	require.NoError(t, db.Create(&models.IdempotencyKey{
+		Client: "client", Key: "key", Fingerprint: "fp", Status: 201, Body: []byte{0, 1, 2, 255},
+		CreatedAt: time.Now().UTC().Truncate(time.Second), ExpiresAt: time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+	}).Error)
+}
+
+func createArchive(t *testing.T, db *gorm.DB, opts Options) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	_, err := Create(context.Background(), db, &buf, opts)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestCreateAndRead(t *testing.T) {
+	db := setup(t)
+	seed(t, db)
+
+	archive, err := Read(bytes.NewReader(createArchive(t, db, Options{})))
+	require.NoError(t, err)
+
+	manifest := archive.Manifest
+	assert.Equal(t, FormatVersion, manifest.Format)
+	assert.Equal(t, uint(1), manifest.SchemaVersion)
+	assert.Equal(t, "sqlite", manifest.Driver)
+	assert.True(t, manifest.Secrets)
+	assert.Equal(t, map[string]int{"users": 2, "settings": 1}, manifest.Tables)
+	assert.Equal(t, "Alice", archive.Tables["users"][0]["name"])
+	assert.Contains(t, archive.Tables["settings"][0]["value"], "plex-token")
+	assert.NotContains(t, archive.Tables, "idempotency_keys")
+	assert.Contains(t, string(archive.Config), `"app"`)
+
+	archive, err = Read(bytes.NewReader(createArchive(t, db, Options{ExcludeSecrets: true})))
+	require.NoError(t, err)
+	assert.False(t, archive.Manifest.Secrets)
+	assert.Equal(t, `{"plex":{"host":"plex.local"}}`, archive.Tables["settings"][0]["value"])
+	assert.NotContains(t, string(archive.Config), "jwtSecret")
+}
+
+func TestRead_RejectsInvalidArchives(t *testing.T) {
+	_, err := Read(bytes.NewReader([]byte("not an archive")))
+	assert.ErrorContains(t, err, "not a backup archive")
+
+	archiveOf := func(manifest Manifest, tables map[string]string) []byte {
+		data, _ := json.Marshal(manifest)
+		entries := map[string][]byte{configEntry: []byte("{}")}
+		for name, rows := range tables {
+			entries[tablesDir+name+".json"] = []byte(rows)
+		}
+		var buf bytes.Buffer
+		require.NoError(t, writeArchive(&buf, data, entries, time.Now()))
+		return buf.Bytes()
+	}
+
+	_, err = Read(bytes.NewReader(archiveOf(Manifest{Format: 2}, nil)))
+	assert.ErrorContains(t, err, "format 2 is not supported")
+
+	_, err = Read(bytes.NewReader(archiveOf(Manifest{Format: FormatVersion, Tables: map[string]int{"users": 2}}, map[string]string{"users": `[{"id":1}]`})))
+	assert.ErrorContains(t, err, "table users should have 2 rows")
+
+	// Without the manifest nothing is known about the archive
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: configEntry, Mode: 0o600, Size: 2}))
+	_, err = io.WriteString(tw, "{}")
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	_, err = Read(&buf)
+	assert.ErrorContains(t, err, "manifest.json is missing")
+}
+
+func TestRestore(t *testing.T) {
+	db := setup(t)
+	seed(t, db)
+	ctx := context.Background()
+
+	data := createArchive(t, db, Options{})
+
+	cfg := *utils.GetStoredConfig()
+	cfg.App.Name = "Changed"
+	require.NoError(t, utils.SaveSettings(cfg))
+	require.NoError(t, db.Where("email = ?", "alice@example.com").Delete(&models.User{}).Error)
+	require.NoError(t, db.Create(&models.User{Name: "Carol", Email: "carol@example.com", Password: "password123"}).Error)
+
+	archive, err := Read(bytes.NewReader(data))
+	require.NoError(t, err)
+	// Archives made before cached responses were left out still hold them
+	archive.Tables["idempotency_keys"] = []map[string]interface{}{{"client": "client", "key": "old"}}
+	require.NoError(t, Restore(ctx, db, archive))
+
+	var users []models.User
+	require.NoError(t, db.Order("id").Find(&users).Error)
+	require.Len(t, users, 2)
+	assert.Equal(t, "alice@example.com", users[0].Email)
+	assert.Equal(t, uint(1), users[0].ID)
+	assert.Equal(t, "bob@example.com", users[1].Email)
+
+	// Cached responses are not archived and do not outlive a restore
+	var keys int64
+	require.NoError(t, db.Model(&models.IdempotencyKey{}).Count(&keys).Error)
+	assert.Equal(t, int64(0), keys)
+
+	assert.Equal(t, "Listarr", utils.GetConfig().App.Name)
+
+	// Restored IDs are not handed out again
+	carol := models.User{Name: "Carol", Email: "carol@example.com", Password: "password123"}
+	require.NoError(t, db.Create(&carol).Error)
+	assert.Greater(t, carol.ID, uint(2))
+
+	// The schema is migrated for an empty database
+	empty := openDB(t, false)
+	require.NoError(t, Restore(ctx, empty, archive))
+	var count int64
+	require.NoError(t, empty.Model(&models.User{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+	migrator, err := database.Migrator(empty)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Check(ctx))
+}
+
+func TestRestore_WithoutSecretsKeepsCurrentOnes(t *testing.T) {
+	db := setup(t)
+	seed(t, db)
+	archive, err := Read(bytes.NewReader(createArchive(t, db, Options{ExcludeSecrets: true})))
+	require.NoError(t, err)
+
+	require.NoError(t, db.Model(&models.Setting{}).Where("section = ?", "integrations").
+		Update("value", `{"plex":{"host":"other.local","token":"new-token"}}`).Error)
+	require.NoError(t, Restore(context.Background(), db, archive))
+
+	var setting models.Setting
+	require.NoError(t, db.First(&setting, "section = ?", "integrations").Error)
+	assert.JSONEq(t, `{"plex":{"host":"plex.local","token":"new-token"}}`, setting.Value)
+}
+
+func TestRestore_RejectsIncompatibleArchives(t *testing.T) {
+	db := setup(t)
+	seed(t, db)
+	data := createArchive(t, db, Options{})
+	ctx := context.Background()
+
+	encoded, err := utils.GenerateMasterKey()
+	require.NoError(t, err)
+	otherKey, err := utils.ParseMasterKey(encoded)
+	require.NoError(t, err)
+	otherSecret, err := utils.EncryptSecret(otherKey, "plex-token")
+	require.NoError(t, err)
+
+	read := func(change func(archive *Archive)) *Archive {
+		archive, err := Read(bytes.NewReader(data))
+		require.NoError(t, err)
+		change(archive)
+		return archive
+	}
+	tests := map[string]struct {
+		change       func(archive *Archive)
+		err          string
+		incompatible bool
+	}{
+		"newer schema": {func(a *Archive) { a.Manifest.SchemaVersion = 99 }, "newer than the latest migration", true},
+		"no schema":    {func(a *Archive) { a.Manifest.SchemaVersion = 0 }, "without any migration applied", true},
+		"other driver": {func(a *Archive) { a.Manifest.Driver = "postgres" }, "made from a postgres database", true},
+		"unknown column": {func(a *Archive) {
+			a.Tables["users"][1]["nickname"] = "bobby"
+		}, "backup has column users.nickname", true},
+		"unknown table": {func(a *Archive) { a.Tables["lists"] = nil }, "backup holds table lists", true},
+		"missing table": {func(a *Archive) { delete(a.Tables, "settings") }, "backup is missing table settings", true},
+		"other master key": {func(a *Archive) {
+			a.Tables["settings"][0]["value"] = `{"plex":{"token":"` + otherSecret + `"}}`
+		}, "error decrypting integrations.plex.token from backup", true},
+		"other master key in config": {func(a *Archive) {
+			a.Config = []byte(`{"auth":{"jwtSecret":"` + otherSecret + `"}}`)
+		}, "error decrypting auth.jwtSecret from backup", true},
+		"invalid values": {func(a *Archive) { a.Tables["users"][0]["id"] = json.Number("x") }, "error restoring users.id", false},
+		// Fails on insert, once the tables were cleared
+		"duplicate rows": {func(a *Archive) {
+			a.Tables["users"][1]["email"] = a.Tables["users"][0]["email"]
+		}, "error restoring table users", false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, db.Create(&models.User{Name: "Carol", Email: name + "@example.com", Password: "password123"}).Error)
+
+			err := Restore(ctx, db, read(test.change))
+			assert.ErrorContains(t, err, test.err)
+			var incompatible *IncompatibleError
+			assert.Equal(t, test.incompatible, errors.As(err, &incompatible))
+
+			// Nothing was changed, schema included
+			var count int64
+			require.NoError(t, db.Model(&models.User{}).Where("email = ?", name+"@example.com").Count(&count).Error)
+			assert.Equal(t, int64(1), count)
+			migrator, err := database.Migrator(db)
+			require.NoError(t, err)
+			assert.NoError(t, migrator.Check(ctx))
+		})
+	}
+}
+
+func TestSchedule_KeepsRetention(t *testing.T) {
+	db := setup(t)
+	dir := filepath.Join(t.TempDir(), "backups")
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	for _, age := range []time.Duration{48, 72, 96} {
+		name := filepath.Join(dir, FileName(time.Now().Add(-age*time.Hour)))
+		require.NoError(t, os.WriteFile(name, nil, 0o600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600))
+
+	cfg := &models.Configuration{}
+	cfg.Backup.Dir = dir
+	cfg.Backup.Interval = 24
+	cfg.Backup.Retention = 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Schedule(ctx, db, func() *models.Configuration { return cfg })
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		files, _ := List(dir)
+		return len(files) == 2 && time.Since(files[0].CreatedAt) < time.Minute
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	files, err := List(dir)
+	require.NoError(t, err)
+	assert.True(t, time.Since(files[1].CreatedAt) > 47*time.Hour)
+	_, err = os.Stat(filepath.Join(dir, "notes.txt"))
+	assert.NoError(t, err)
+
+	// The newest archive is recent enough, so nothing is written
+	require.NoError(t, runScheduled(context.Background(), db, cfg))
+	again, err := List(dir)
+	require.NoError(t, err)
+	assert.Equal(t, files, again)
+
+	archive, err := os.Open(files[0].Path)
+	require.NoError(t, err)
+	defer archive.Close()
+	_, err = Read(archive)
+	assert.NoError(t, err)
+}
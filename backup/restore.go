@@ -0,0 +1,293 @@
+// backup/restore.go
+package backup
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"listarr-backend/database"
+	"listarr-backend/migrations"
+	"listarr-backend/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// insertBatchSize is the number of rows inserted per statement
+const insertBatchSize = 100
+
+// IncompatibleError reports an archive whose data does not fit the schema
+// of this build
+type IncompatibleError struct {
+	Message string
+}
+
+func (e *IncompatibleError) Error() string {
+	return e.Message
+}
+
+// Restore replaces the data of db and app.config.json with those of archive
+// and reloads the configuration. db may be empty or hold data, which is
+// deleted. Archives from another database driver, of a newer schema or with
+// secrets encrypted with another master key are refused before anything is
+// touched. Everything else runs in one
+// transaction: the schema is migrated to the version of the archive, every
+// table and column of the archive is checked against it, the rows are
+// replaced and the schema is migrated back to the latest version, carrying
+// the restored data forward. Any failure rolls all of it back, so the
+// database is either fully restored or left as it was.
+func Restore(ctx context.Context, db *gorm.DB, archive *Archive) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	manifest := archive.Manifest
+	if driver := db.Dialector.Name(); manifest.Driver != driver {
+		return &IncompatibleError{Message: fmt.Sprintf("backup was made from a %s database and cannot be restored into %s", manifest.Driver, driver)}
+	}
+	if manifest.SchemaVersion == 0 {
+		return &IncompatibleError{Message: "backup was made from a database without any migration applied"}
+	}
+
+	migrator, err := database.Migrator(db)
+	if err != nil {
+		return err
+	}
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if manifest.SchemaVersion > status.Latest {
+		return &IncompatibleError{Message: fmt.Sprintf("backup has schema version %d, newer than the latest migration %d of this build; restore it with Listarr %s or later",
+			manifest.SchemaVersion, status.Latest, manifest.AppVersion)}
+	}
+
+	// Secrets of app.config.json are checked here as the file is only
+	// replaced once the database is restored
+	var configMap map[string]interface{}
+	if err := json.Unmarshal(archive.Config, &configMap); err != nil {
+		return fmt.Errorf("error parsing config from backup: %w", err)
+	}
+	if err := utils.CheckSecretsDecrypt(configMap); err != nil {
+		return &IncompatibleError{Message: err.Error()}
+	}
+
+	// Migrations and large tables may take longer than db.timeout
+	ctx = database.WithoutQueryTimeout(ctx)
+	err = migrator.Transaction(ctx, func(tx *migrations.Tx) error {
+		// A backup without secrets keeps those of the current settings,
+		// read before migrating may drop the table
+		var currentSettings []map[string]interface{}
+		if !manifest.Secrets && tx.DB.Migrator().HasTable(settingsTable) {
+			if err := tx.DB.Table(settingsTable).Find(&currentSettings).Error; err != nil {
+				return fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
+		if _, err := tx.MigrateTo(manifest.SchemaVersion); err != nil {
+			return fmt.Errorf("error migrating to the schema version %d of the backup: %w", manifest.SchemaVersion, err)
+		}
+		if err := load(tx.DB, archive, currentSettings); err != nil {
+			return err
+		}
+		if _, err := tx.Up(); err != nil {
+			return fmt.Errorf("error migrating the restored data: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := utils.RestoreConfigFile(archive.Config, !manifest.Secrets); err != nil {
+		return fmt.Errorf("database restored but the configuration was not: %w", err)
+	}
+	return nil
+}
+
+// load replaces the rows of every table with those of archive, after
+// checking that the archive has exactly the tables of the schema and only
+// columns it knows. idempotencyTable is only cleared. currentSettings, when not nil, fill the secrets missing
+// from the restored settings.
+func load(tx *gorm.DB, archive *Archive, currentSettings []map[string]interface{}) error {
+	names, err := tableNames(tx)
+	if err != nil {
+		return err
+	}
+	version := archive.Manifest.SchemaVersion
+	exists := map[string]bool{}
+	for _, name := range names {
+		exists[name] = true
+		if _, ok := archive.Tables[name]; !ok && name != idempotencyTable {
+			return &IncompatibleError{Message: fmt.Sprintf("backup is missing table %s of schema version %d", name, version)}
+		}
+	}
+	for name := range archive.Tables {
+		if !exists[name] {
+			return &IncompatibleError{Message: fmt.Sprintf("backup holds table %s, which schema version %d does not have", name, version)}
+		}
+	}
+
+	// Every row is converted before the first one is deleted. Archives made
+	// before idempotencyTable was left out still hold it; it is cleared all
+	// the same.
+	tables := map[string][]map[string]interface{}{}
+	for _, name := range names {
+		if name == idempotencyTable {
+			continue
+		}
+		rows, err := convertRows(tx, name, archive.Tables[name])
+		if err != nil {
+			return err
+		}
+		if name == settingsTable {
+			if currentSettings != nil {
+				if err := copySettingsSecrets(rows, currentSettings); err != nil {
+					return err
+				}
+			}
+			if err := checkSettingsSecrets(rows); err != nil {
+				return err
+			}
+		}
+		tables[name] = rows
+	}
+
+	for _, name := range names {
+		if err := tx.Exec("DELETE FROM ?", clause.Table{Name: name}).Error; err != nil {
+			return fmt.Errorf("error clearing table %s: %w", name, err)
+		}
+		if len(tables[name]) == 0 {
+			continue
+		}
+		if err := tx.Table(name).CreateInBatches(tables[name], insertBatchSize).Error; err != nil {
+			return fmt.Errorf("error restoring table %s: %w", name, err)
+		}
+		if tx.Dialector.Name() == "postgres" {
+			if err := resetSequences(tx, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// convertRows copies rows with their JSON values turned into the Go types of
+// the columns of table, so bytes, times and booleans are stored as such in
+// either database
+func convertRows(tx *gorm.DB, table string, rows []map[string]interface{}) ([]map[string]interface{}, error) {
+	columnTypes, err := tx.Migrator().ColumnTypes(table)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the columns of %s: %w", table, err)
+	}
+	types := map[string]string{}
+	for _, column := range columnTypes {
+		types[column.Name()] = strings.ToUpper(column.DatabaseTypeName())
+	}
+
+	converted := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		converted[i] = make(map[string]interface{}, len(row))
+		for column, value := range row {
+			typeName, ok := types[column]
+			if !ok {
+				return nil, &IncompatibleError{Message: fmt.Sprintf("backup has column %s.%s, which the database does not", table, column)}
+			}
+			if converted[i][column], err = convertValue(value, typeName); err != nil {
+				return nil, fmt.Errorf("error restoring %s.%s: %w", table, column, err)
+			}
+		}
+	}
+	return converted, nil
+}
+
+// convertValue converts a value decoded from JSON to the Go type of a column
+// of database type typeName
+func convertValue(value interface{}, typeName string) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		switch {
+		case strings.Contains(typeName, "BOOL"):
+			n, err := v.Int64()
+			return n != 0, err
+		case strings.Contains(typeName, "INT") || strings.Contains(typeName, "SERIAL"):
+			return v.Int64()
+		}
+		return v.Float64()
+	case string:
+		switch {
+		case strings.Contains(typeName, "BYTEA") || strings.Contains(typeName, "BLOB"):
+			return base64.StdEncoding.DecodeString(v)
+		case strings.Contains(typeName, "TIME") || strings.Contains(typeName, "DATE"):
+			// Times are written in RFC 3339; anything else is stored as is
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return value, nil
+}
+
+// copySettingsSecrets fills the secrets missing from restored settings rows
+// with their values in the current rows
+func copySettingsSecrets(rows, current []map[string]interface{}) error {
+	currentValues := map[string]interface{}{}
+	for _, row := range current {
+		name, _ := row["section"].(string)
+		value, _ := row["value"].(string)
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			return fmt.Errorf("error parsing %s settings: %w", name, err)
+		}
+		currentValues[name] = decoded
+	}
+
+	for _, row := range rows {
+		err := updateSection(row, func(section map[string]interface{}) {
+			utils.CopySecrets(section, currentValues)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSettingsSecrets returns an error when a secret of the restored settings
+// rows cannot be decrypted with the current master key
+func checkSettingsSecrets(rows []map[string]interface{}) error {
+	for _, row := range rows {
+		name, _ := row["section"].(string)
+		value, _ := row["value"].(string)
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			return fmt.Errorf("error parsing %s settings: %w", name, err)
+		}
+		if err := utils.CheckSecretsDecrypt(map[string]interface{}{name: decoded}); err != nil {
+			return &IncompatibleError{Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// resetSequences moves the sequences of the serial columns of table past the
+// restored rows, so new rows do not reuse their IDs
+func resetSequences(tx *gorm.DB, table string) error {
+	var columns []string
+	err := tx.Raw(`SELECT column_name FROM information_schema.columns
+		WHERE table_schema = CURRENT_SCHEMA() AND table_name = ? AND column_default LIKE 'nextval(%'`, table).Scan(&columns).Error
+	if err != nil {
+		return fmt.Errorf("error reading the sequences of %s: %w", table, err)
+	}
+
+	for _, column := range columns {
+		err := tx.Exec("SELECT setval(pg_get_serial_sequence(?, ?), COALESCE(MAX(?), 0) + 1, false) FROM ?",
+			table, column, clause.Column{Name: column}, clause.Table{Name: table}).Error
+		if err != nil {
+			return fmt.Errorf("error resetting the sequence of %s.%s: %w", table, column, err)
+		}
+	}
+	return nil
+}
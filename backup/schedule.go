@@ -0,0 +1,144 @@
+// backup/schedule.go
+package backup
+
+import (
+	"context"
+	"fmt"
+	"listarr-backend/models"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// checkInterval is how often Schedule looks for a due backup, and
+// retryDelay how long it waits after a failed one; both are replaced in tests
+var (
+	checkInterval = time.Minute
+	retryDelay    = 15 * time.Minute
+)
+
+// fileNamePattern matches the names given by FileName
+var fileNamePattern = regexp.MustCompile(`^listarr_backup_(\d{8}_\d{6})\.tar\.gz$`)
+
+// File is an archive in the backup directory
+type File struct {
+	Path      string
+	CreatedAt time.Time
+}
+
+// WriteFile writes an archive named by FileName to dir and returns its path.
+// The archive only gets its name once complete, so a failed backup never
+// looks like the latest one.
+func WriteFile(ctx context.Context, db *gorm.DB, dir string, opts Options) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("error creating backup directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".listarr_backup_*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("error creating backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	manifest, err := Create(ctx, db, tmp, opts)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error writing backup file: %w", closeErr)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, FileName(manifest.CreatedAt))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("error naming backup file: %w", err)
+	}
+	return path, nil
+}
+
+// List returns the archives in dir named by FileName, newest first. A
+// missing directory holds no archives.
+func List(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup directory: %w", err)
+	}
+
+	var files []File
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		created, err := time.Parse("20060102_150405", match[1])
+		if err != nil {
+			continue
+		}
+		files = append(files, File{Path: filepath.Join(dir, entry.Name()), CreatedAt: created})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.After(files[j].CreatedAt) })
+	return files, nil
+}
+
+// Schedule writes an archive to backup.dir every backup.interval hours and
+// keeps the newest backup.retention, until ctx is cancelled. Settings
+// changes apply at the next check. The last backup is found in the
+// directory, so restarts neither repeat nor postpone one.
+func Schedule(ctx context.Context, db *gorm.DB, config func() *models.Configuration) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	var failedAt time.Time
+	for {
+		if cfg := config(); cfg != nil && cfg.Backup.Interval > 0 && time.Since(failedAt) >= retryDelay {
+			if err := runScheduled(ctx, db, cfg); err != nil {
+				slog.Error("Scheduled backup failed", "dir", cfg.Backup.Dir, "error", err)
+				failedAt = time.Now()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runScheduled writes an archive when the newest in dir is older than the
+// interval, then deletes those beyond the retention
+func runScheduled(ctx context.Context, db *gorm.DB, cfg *models.Configuration) error {
+	files, err := List(cfg.Backup.Dir)
+	if err != nil {
+		return err
+	}
+	interval := time.Duration(cfg.Backup.Interval) * time.Hour
+	if len(files) > 0 && time.Since(files[0].CreatedAt) < interval {
+		return nil
+	}
+
+	path, err := WriteFile(ctx, db, cfg.Backup.Dir, Options{ExcludeSecrets: cfg.Backup.ExcludeSecrets})
+	if err != nil {
+		return err
+	}
+	slog.Info("Backup written", "path", path)
+
+	files, err = List(cfg.Backup.Dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files[min(len(files), max(cfg.Backup.Retention, 1)):] {
+		if err := os.Remove(file.Path); err != nil {
+			return fmt.Errorf("error deleting old backup: %w", err)
+		}
+		slog.Info("Old backup deleted", "path", file.Path)
+	}
+	return nil
+}
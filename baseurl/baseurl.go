@@ -0,0 +1,164 @@
+// baseurl/baseurl.go
+package baseurl
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ForwardedPrefixHeader is set by proxies that strip a path prefix
+	ForwardedPrefixHeader = "X-Forwarded-Prefix"
+	// ForwardedProtoHeader is set by proxies that terminate TLS
+	ForwardedProtoHeader = "X-Forwarded-Proto"
+
+	urlBaseKey = "baseurl.urlBase"
+	prefixKey  = "baseurl.prefix"
+	schemeKey  = "baseurl.scheme"
+)
+
+// validPrefix allows path segments of URL-safe characters only, so a
+// forwarded prefix cannot inject anything into generated links
+var validPrefix = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// Normalize returns urlBase with a leading slash and without a trailing one,
+// or an empty string for the root, so "listarr/" becomes "/listarr"
+func Normalize(urlBase string) string {
+	urlBase = strings.Trim(strings.TrimSpace(urlBase), "/")
+	if urlBase == "" {
+		return ""
+	}
+	return path.Clean("/" + urlBase)
+}
+
+// Middleware records urlBase, the path prefix routes are mounted under, for
+// the helpers of this package. For requests from trustedProxies it also
+// records X-Forwarded-Prefix, for proxies that strip the prefix before
+// forwarding, and X-Forwarded-Proto; these headers are ignored from other
+// clients.
+func Middleware(urlBase string, trustedProxies []string) (gin.HandlerFunc, error) {
+	urlBase = Normalize(urlBase)
+	trusted, err := parseNetworks(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		c.Set(urlBaseKey, urlBase)
+
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+
+		if isTrusted(c.Request.RemoteAddr, trusted) {
+			if prefix := firstValue(c.GetHeader(ForwardedPrefixHeader)); prefix != "" {
+				prefix = strings.TrimSuffix(path.Clean(prefix), "/")
+				if validPrefix.MatchString(prefix) {
+					c.Set(prefixKey, prefix)
+				}
+			}
+			switch proto := strings.ToLower(firstValue(c.GetHeader(ForwardedProtoHeader))); proto {
+			case "http", "https":
+				scheme = proto
+			}
+		} else {
+			// Keep gin's trailing slash redirects from using a spoofed prefix
+			c.Request.Header.Del(ForwardedPrefixHeader)
+		}
+		c.Set(schemeKey, scheme)
+
+		c.Next()
+	}, nil
+}
+
+// Base returns the external path of the application root as seen by the
+// browser, such as /listarr, or an empty string at the root
+func Base(c *gin.Context) string {
+	return c.GetString(prefixKey) + c.GetString(urlBaseKey)
+}
+
+// Strip returns the request path relative to urlBase, and false for requests
+// outside it
+func Strip(c *gin.Context) (string, bool) {
+	urlBase := c.GetString(urlBaseKey)
+	p := c.Request.URL.Path
+	if urlBase == "" {
+		return p, true
+	}
+	if p == urlBase {
+		return "/", true
+	}
+	if rest, ok := strings.CutPrefix(p, urlBase+"/"); ok {
+		return "/" + rest, true
+	}
+	return "", false
+}
+
+// Path returns the external path of p, a path on this server such as
+// c.Request.URL.Path, adding any prefix stripped by a trusted proxy
+func Path(c *gin.Context, p string) string {
+	return c.GetString(prefixKey) + p
+}
+
+// URL returns the absolute external URL of p
+func URL(c *gin.Context, p string) string {
+	scheme := c.GetString(schemeKey)
+	if scheme == "" {
+		scheme = "http"
+	}
+	return scheme + "://" + c.Request.Host + Path(c, p)
+}
+
+func parseNetworks(entries []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func isTrusted(remoteAddr string, trusted []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstValue returns the value set by the proxy closest to the client when
+// several proxies appended to a header
+func firstValue(header string) string {
+	value, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,114 @@
+package baseurl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	for input, want := range map[string]string{
+		"":           "",
+		"/":          "",
+		"listarr":    "/listarr",
+		"/listarr/":  "/listarr",
+		" /a//b/ ":   "/a/b",
+		"/media/app": "/media/app",
+	} {
+		assert.Equal(t, want, Normalize(input), input)
+	}
+}
+
+type seen struct {
+	base, path, url, strip string
+	inside                 bool
+}
+
+func serve(t *testing.T, urlBase, remoteAddr, target string, headers map[string]string) seen {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	forwarded, err := Middleware(urlBase, []string{"10.0.0.0/8", "::1"})
+	require.NoError(t, err)
+
+	var got seen
+	r := gin.New()
+	r.Use(forwarded)
+	r.NoRoute(func(c *gin.Context) {
+		got.base = Base(c)
+		got.path = Path(c, c.Request.URL.Path)
+		got.url = URL(c, c.Request.URL.Path)
+		got.strip, got.inside = Strip(c)
+	})
+
+	req := httptest.NewRequest("GET", target, nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return got
+}
+
+func TestMiddleware_URLBase(t *testing.T) {
+	got := serve(t, "/listarr", "192.0.2.1:1234", "http://media.example.com/listarr/api/v1/users", nil)
+	assert.Equal(t, seen{
+		base:   "/listarr",
+		path:   "/listarr/api/v1/users",
+		url:    "http://media.example.com/listarr/api/v1/users",
+		strip:  "/api/v1/users",
+		inside: true,
+	}, got)
+
+	got = serve(t, "/listarr", "192.0.2.1:1234", "/listarrx/api", nil)
+	assert.False(t, got.inside)
+	got = serve(t, "/listarr", "192.0.2.1:1234", "/listarr", nil)
+	assert.Equal(t, "/", got.strip)
+}
+
+func TestMiddleware_ForwardedHeaders(t *testing.T) {
+	headers := map[string]string{
+		ForwardedPrefixHeader: "/listarr/",
+		ForwardedProtoHeader:  "https, http",
+	}
+
+	// A trusted proxy that strips the prefix
+	got := serve(t, "", "10.1.2.3:1234", "http://media.example.com/api/v1/users", headers)
+	assert.Equal(t, "/listarr", got.base)
+	assert.Equal(t, "/listarr/api/v1/users", got.path)
+	assert.Equal(t, "https://media.example.com/listarr/api/v1/users", got.url)
+	assert.Equal(t, "/api/v1/users", got.strip)
+
+	got = serve(t, "", "[::1]:1234", "http://media.example.com/", headers)
+	assert.Equal(t, "/listarr", got.base)
+
+	// Other clients cannot change generated links
+	got = serve(t, "", "192.0.2.1:1234", "http://media.example.com/api/v1/users", headers)
+	assert.Equal(t, "", got.base)
+	assert.Equal(t, "http://media.example.com/api/v1/users", got.url)
+
+	// Malformed prefixes are ignored
+	got = serve(t, "", "10.1.2.3:1234", "/", map[string]string{ForwardedPrefixHeader: `/a"><script>`})
+	assert.Equal(t, "", got.base)
+}
+
+func TestMiddleware_InvalidProxy(t *testing.T) {
+	_, err := Middleware("", []string{"not-an-ip"})
+	assert.Error(t, err)
+	_, err = Middleware("", []string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestHelpersWithoutMiddleware(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/users", nil)
+
+	assert.Equal(t, "", Base(c))
+	assert.Equal(t, "http://localhost:8080/api/v1/users", URL(c, c.Request.URL.Path))
+	path, ok := Strip(c)
+	assert.True(t, ok)
+	assert.Equal(t, "/api/v1/users", path)
+}
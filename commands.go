@@ -0,0 +1,278 @@
+// commands.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"listarr-backend/backup"
+	"listarr-backend/database"
+	"listarr-backend/utils"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// command is a maintenance task run instead of the API server
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+var commands = map[string]command{
+	"genkey": {
+		description: "Generate a new master key for encrypting secrets",
+		run:         runGenKey,
+	},
+	"rotate-key": {
+		description: "Re-encrypt config and settings secrets with a new master key",
+		run:         runRotateKey,
+	},
+	"migrate": {
+		description: "Apply, roll back or list database migrations: migrate up|down|status",
+		run:         runMigrate,
+	},
+	"backup": {
+		description: "Write a backup of the database and configuration: backup [-o file] [-exclude-secrets]",
+		run:         runBackup,
+	},
+	"restore": {
+		description: "Replace the database and configuration with a backup: restore <file>",
+		run:         runRestore,
+	},
+}
+
+// runCommand executes the named command and reports whether one was found
+func runCommand(args []string) bool {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return false
+	}
+
+	if err := cmd.run(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+	return true
+}
+
+func runGenKey(args []string) error {
+	key, err := utils.GenerateMasterKey()
+	if err != nil {
+		return err
+	}
+	fmt.Println(key)
+	return nil
+}
+
+func runRotateKey(args []string) error {
+	fs := flag.NewFlagSet("rotate-key", flag.ExitOnError)
+	newKeyValue := fs.String("new-key", "", "base64 encoded master key to encrypt with")
+	newKeyFile := fs.String("new-key-file", "", "file containing the new base64 encoded master key")
+	fs.Parse(args)
+
+	if *newKeyFile != "" {
+		data, err := os.ReadFile(*newKeyFile)
+		if err != nil {
+			return fmt.Errorf("error reading new key file: %w", err)
+		}
+		*newKeyValue = string(data)
+	}
+	if *newKeyValue == "" {
+		return fmt.Errorf("one of -new-key or -new-key-file is required")
+	}
+
+	newKey, err := utils.ParseMasterKey(*newKeyValue)
+	if err != nil {
+		return err
+	}
+
+	// The current key comes from the usual environment variables
+	oldKey, err := utils.LoadMasterKey()
+	if err != nil {
+		return err
+	}
+
+	if err := utils.InitConfig(); err != nil {
+		return err
+	}
+	// With the database backend the settings table holds secrets as well,
+	// reached through the db section of app.config.json
+	var db *gorm.DB
+	if cfg := utils.GetConfig(); cfg.Settings.Backend == "database" {
+		db, err = database.Connect(context.Background(), cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := utils.RotateMasterKey(oldKey, newKey, db); err != nil {
+		return err
+	}
+
+	fmt.Printf("Secrets re-encrypted. Update %s or %s with the new key before restarting.\n",
+		utils.MasterKeyEnv, utils.MasterKeyFileEnv)
+	return nil
+}
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up [-retry] | down [-steps n] | status")
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	retry := fs.Bool("retry", false, "forget a failed migration and run it again")
+	steps := fs.Int("steps", 1, "number of migrations to roll back")
+	fs.Parse(args[1:])
+
+	if err := utils.InitConfig(); err != nil {
+		return err
+	}
+	// Migrations may run longer than db.timeout
+	ctx := database.WithoutQueryTimeout(context.Background())
+	db, err := database.Connect(ctx, utils.GetConfig())
+	if err != nil {
+		return err
+	}
+	migrator, err := database.Migrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		if *retry {
+			if err := migrator.ClearFailed(ctx); err != nil {
+				return err
+			}
+		}
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+	case "down":
+		if *steps < 1 {
+			return fmt.Errorf("-steps must be at least 1")
+		}
+		rolledBack, err := migrator.Down(ctx, *steps)
+		for _, m := range rolledBack {
+			fmt.Printf("Rolled back %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("No migrations to roll back")
+		}
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, r := range status.Applied {
+			fmt.Fprintf(w, "%d\t%s\tapplied %s\n", r.Version, r.Name, r.AppliedAt.Local().Format(time.DateTime))
+		}
+		if r := status.Failed; r != nil {
+			fmt.Fprintf(w, "%d\t%s\tfailed %s: %s\n", r.Version, r.Name, r.AppliedAt.Local().Format(time.DateTime), r.Error)
+		}
+		for _, m := range status.Pending {
+			if status.Failed == nil || m.Version != status.Failed.Version {
+				fmt.Fprintf(w, "%d\t%s\tpending\n", m.Version, m.Name)
+			}
+		}
+		w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+	return nil
+}
+
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := fs.String("o", "", "file to write, by default a new archive in backup.dir")
+	excludeSecrets := fs.Bool("exclude-secrets", false, "leave passwords, tokens and API keys out")
+	fs.Parse(args)
+
+	if err := utils.InitConfig(); err != nil {
+		return err
+	}
+	cfg := utils.GetConfig()
+	ctx := context.Background()
+	db, err := database.Connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	opts := backup.Options{ExcludeSecrets: *excludeSecrets}
+
+	if *output == "" {
+		path, err := backup.WriteFile(ctx, db, cfg.Backup.Dir, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Backup written to %s\n", path)
+		return nil
+	}
+
+	if dir := filepath.Dir(*output); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("error creating output directory: %w", err)
+		}
+	}
+	file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("error creating backup file: %w", err)
+	}
+	if _, err := backup.Create(ctx, db, file, opts); err != nil {
+		file.Close()
+		os.Remove(*output)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing backup file: %w", err)
+	}
+	fmt.Printf("Backup written to %s\n", *output)
+	return nil
+}
+
+func runRestore(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: restore <file>")
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("error opening backup: %w", err)
+	}
+	defer file.Close()
+	archive, err := backup.Read(file)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.InitConfig(); err != nil {
+		return err
+	}
+	db, err := database.Connect(context.Background(), utils.GetConfig())
+	if err != nil {
+		return err
+	}
+	if err := backup.Restore(context.Background(), db, archive); err != nil {
+		return err
+	}
+
+	m := archive.Manifest
+	fmt.Printf("Restored the backup of %s (Listarr %s, schema version %d)\n",
+		m.CreatedAt.Local().Format(time.DateTime), m.AppVersion, m.SchemaVersion)
+	return nil
+}
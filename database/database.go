@@ -0,0 +1,197 @@
+// database/database.go
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"listarr-backend/migrations"
+	"listarr-backend/models"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// initialRetryDelay and maxRetryDelay bound the backoff between connection
+// attempts at startup; they and open are replaced in tests
+var (
+	initialRetryDelay = time.Second
+	maxRetryDelay     = 15 * time.Second
+	open              = Open
+)
+
+// Connect opens the database, retrying with exponential backoff while it is
+// unreachable for up to db.startupTimeout seconds, such as when Postgres
+// starts after the server in docker-compose. Rejected credentials are not
+// retried.
+func Connect(ctx context.Context, cfg *models.Configuration) (*gorm.DB, error) {
+	deadline := time.Now().Add(time.Duration(cfg.Db.StartupTimeout) * time.Second)
+	delay := initialRetryDelay
+
+	for attempt := 1; ; attempt++ {
+		db, err := open(cfg)
+		if err == nil {
+			if attempt > 1 {
+				slog.Info("Connected to database", "attempts", attempt)
+			}
+			return db, nil
+		}
+
+		if !retryable(cfg, err) {
+			return nil, err
+		}
+		if time.Now().Add(delay).After(deadline) {
+			if attempt == 1 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("database still unreachable after %d attempts: %w", attempt, err)
+		}
+
+		slog.Warn("Database unreachable, retrying", "attempt", attempt, "retryIn", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// retryable reports whether a failed connection may succeed later
+func retryable(cfg *models.Configuration, err error) bool {
+	// A SQLite file is either usable or not
+	if cfg.Db.Driver == "sqlite" {
+		return false
+	}
+	// Class 28 is invalid authorization, which waiting does not fix
+	var pgErr *pgconn.PgError
+	return !errors.As(err, &pgErr) || !strings.HasPrefix(pgErr.Code, "28")
+}
+
+// Open connects to the database described by the db section of cfg and
+// applies the pool limits and query timeout
+func Open(cfg *models.Configuration) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.Db.Driver {
+	case "sqlite":
+		dsn, err := sqliteDSN(cfg)
+		if err != nil {
+			return nil, err
+		}
+		dialector = sqlite.Open(dsn)
+	default:
+		dialector = postgres.Open(postgresDSN(cfg))
+	}
+
+	// TranslateError maps unique violations to gorm.ErrDuplicatedKey
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.Db.MaxConns)
+	sqlDB.SetMaxIdleConns(min(cfg.Db.MaxIdleConns, cfg.Db.MaxConns))
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.Db.ConnMaxLifetime) * time.Second)
+	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.Db.ConnMaxIdleTime) * time.Second)
+
+	if cfg.Db.Timeout > 0 {
+		if err := db.Use(queryTimeout{timeout: time.Duration(cfg.Db.Timeout) * time.Second}); err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// postgresDSN builds a keyword/value connection string, quoting every value
+// so passwords and paths may contain spaces and quotes
+func postgresDSN(cfg *models.Configuration) string {
+	sslMode := cfg.Db.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	params := [][2]string{
+		{"host", cfg.Db.Host},
+		{"port", cfg.Db.Port},
+		{"dbname", cfg.Db.Name},
+		{"user", cfg.Db.User},
+		{"password", cfg.Db.Password},
+		{"sslmode", sslMode},
+		{"sslrootcert", cfg.Db.SSLRootCert},
+		{"connect_timeout", strconv.Itoa(cfg.Db.Timeout)},
+	}
+
+	var dsn []string
+	for _, param := range params {
+		if param[1] == "" || (param[0] == "connect_timeout" && param[1] == "0") {
+			continue
+		}
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(param[1])
+		dsn = append(dsn, param[0]+"='"+value+"'")
+	}
+	return strings.Join(dsn, " ")
+}
+
+// sqliteDSN opens db.path in WAL mode, so reads do not wait for writers, and
+// waits up to db.timeout for a write lock instead of failing with
+// SQLITE_BUSY. Transactions take the write lock when they begin, as they may
+// read a row before updating it.
+func sqliteDSN(cfg *models.Configuration) (string, error) {
+	if dir := filepath.Dir(cfg.Db.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("error creating database directory: %w", err)
+		}
+	}
+
+	query := url.Values{}
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "synchronous(NORMAL)")
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", cfg.Db.Timeout*1000))
+	query.Set("_txlock", "immediate")
+	return cfg.Db.Path + "?" + query.Encode(), nil
+}
+
+// Migrator returns the migrator for the schema of db
+func Migrator(db *gorm.DB) (*migrations.Migrator, error) {
+	if db.Dialector.Name() == "sqlite" {
+		return migrations.New(db, migrations.SQLite)
+	}
+	return migrations.New(db, migrations.Postgres)
+}
+
+// Prepare brings the schema up to date when db.migrateOnStart is set and
+// returns an error unless every migration has been applied, so the server
+// never serves a missing or half migrated schema
+func Prepare(ctx context.Context, db *gorm.DB, cfg *models.Configuration) error {
+	migrator, err := Migrator(db)
+	if err != nil {
+		return err
+	}
+
+	// Migrations may run longer than db.timeout
+	ctx = WithoutQueryTimeout(ctx)
+	if cfg.Db.MigrateOnStart {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) > 0 {
+			slog.Info("Database migrated", "applied", len(applied), "version", applied[len(applied)-1].Version)
+		}
+	}
+	return migrator.Check(ctx)
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"listarr-backend/database/dbtest"
+	"listarr-backend/models"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func sqliteConfig(t *testing.T) *models.Configuration {
+	cfg := &models.Configuration{}
+	cfg.Db.Driver = "sqlite"
+	cfg.Db.Path = filepath.Join(t.TempDir(), "data", "listarr.db")
+	cfg.Db.MaxConns = 4
+	cfg.Db.MaxIdleConns = 10
+	cfg.Db.Timeout = 5
+	return cfg
+}
+
+func TestPostgresDSN(t *testing.T) {
+	cfg := &models.Configuration{}
+	cfg.Db.Host = "db"
+	cfg.Db.Port = "5432"
+	cfg.Db.Name = "listarr"
+	cfg.Db.User = "listarr"
+	cfg.Db.Password = `it's a \\secret`
+	cfg.Db.Timeout = 10
+	assert.Equal(t, `host='db' port='5432' dbname='listarr' user='listarr' password='it\'s a \\\\secret' sslmode='disable' connect_timeout='10'`, postgresDSN(cfg))
+
+	cfg.Db.SSLMode = "verify-full"
+	cfg.Db.SSLRootCert = "/etc/listarr/db ca.pem"
+	assert.Contains(t, postgresDSN(cfg), `sslmode='verify-full' sslrootcert='/etc/listarr/db ca.pem'`)
+}
+
+func TestOpenSQLite(t *testing.T) {
+	db, err := Open(sqliteConfig(t))
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	assert.Equal(t, 4, sqlDB.Stats().MaxOpenConnections)
+
+	var mode string
+	require.NoError(t, db.Raw("PRAGMA journal_mode").Scan(&mode).Error)
+	assert.Equal(t, "wal", mode)
+	var busyTimeout int
+	require.NoError(t, db.Raw("PRAGMA busy_timeout").Scan(&busyTimeout).Error)
+	assert.Equal(t, 5000, busyTimeout)
+}
+
+type row struct {
+	ID   uint
+	Name string
+}
+
+func TestQueryTimeout(t *testing.T) {
+	db := dbtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&row{}))
+
+	require.NoError(t, db.Use(queryTimeout{timeout: time.Minute}))
+	// Writes commit after the statement timeout has ended
+	require.NoError(t, db.Create(&row{Name: "kept"}).Error)
+	require.NoError(t, db.Model(&row{}).Where("name = ?", "kept").Update("name", "updated").Error)
+	var rows []row
+	require.NoError(t, db.Find(&rows).Error)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "updated", rows[0].Name)
+
+	expired := dbtest.Open(t)
+	require.NoError(t, expired.AutoMigrate(&row{}))
+	require.NoError(t, expired.Use(queryTimeout{timeout: time.Nanosecond}))
+
+	assert.ErrorIs(t, expired.Find(&rows).Error, context.DeadlineExceeded)
+	assert.ErrorIs(t, expired.Create(&row{Name: "lost"}).Error, context.DeadlineExceeded)
+	assert.ErrorIs(t, expired.Exec("DELETE FROM rows").Error, context.DeadlineExceeded)
+
+	// Contexts with their own deadline, and migrations, are left alone
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	assert.NoError(t, expired.WithContext(ctx).Find(&rows).Error)
+	assert.NoError(t, expired.WithContext(WithoutQueryTimeout(context.Background())).Find(&rows).Error)
+}
+
+func TestConnectRetries(t *testing.T) {
+	initialRetryDelay, maxRetryDelay = time.Millisecond, 2*time.Millisecond
+	t.Cleanup(func() {
+		initialRetryDelay, maxRetryDelay = time.Second, 15*time.Second
+		open = Open
+	})
+
+	cfg := &models.Configuration{}
+	cfg.Db.Driver = "postgres"
+	cfg.Db.StartupTimeout = 5
+
+	attempts := 0
+	open = func(*models.Configuration) (*gorm.DB, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return &gorm.DB{}, nil
+	}
+	db, err := Connect(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.NotNil(t, db)
+	assert.Equal(t, 3, attempts)
+
+	t.Run("rejected credentials are not retried", func(t *testing.T) {
+		attempts = 0
+		open = func(*models.Configuration) (*gorm.DB, error) {
+			attempts++
+			return nil, &pgconn.PgError{Code: "28P01", Message: "password authentication failed"}
+		}
+		_, err := Connect(context.Background(), cfg)
+		assert.ErrorContains(t, err, "password authentication failed")
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("gives up after the startup timeout", func(t *testing.T) {
+		cfg.Db.StartupTimeout = 0
+		attempts = 0
+		open = func(*models.Configuration) (*gorm.DB, error) {
+			attempts++
+			return nil, errors.New("connection refused")
+		}
+		_, err := Connect(context.Background(), cfg)
+		assert.EqualError(t, err, "connection refused")
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		cfg.Db.StartupTimeout = 60
+		ctx, cancel := context.WithCancel(context.Background())
+		open = func(*models.Configuration) (*gorm.DB, error) {
+			cancel()
+			return nil, errors.New("connection refused")
+		}
+		_, err := Connect(ctx, cfg)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,38 @@
+// database/dbtest/dbtest.go
+package dbtest
+
+import (
+	"context"
+	"listarr-backend/migrations"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Open returns an empty in-memory SQLite database, configured like
+// database.Open and closed when the test ends
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// OpenMigrated returns an in-memory SQLite database with the schema of the
+// embedded migrations, the same the server runs against
+func OpenMigrated(t testing.TB) *gorm.DB {
+	t.Helper()
+	db := Open(t)
+	m, err := migrations.New(db, migrations.SQLite)
+	require.NoError(t, err)
+	_, err = m.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
@@ -0,0 +1,89 @@
+// database/timeout.go
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const queryTimeoutCancel = "listarr:query_timeout_cancel"
+
+type noQueryTimeoutKey struct{}
+
+// WithoutQueryTimeout marks ctx so its statements may run longer than
+// db.timeout, as migrations on large tables do
+func WithoutQueryTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noQueryTimeoutKey{}, true)
+}
+
+// queryTimeout is a GORM plugin bounding every statement whose context has no
+// deadline of its own, so a stuck query fails instead of holding a request
+// and a connection forever
+type queryTimeout struct {
+	timeout time.Duration
+}
+
+func (queryTimeout) Name() string {
+	return "listarr:query_timeout"
+}
+
+func (p queryTimeout) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	// Writes run in a transaction begun with the caller's context; the
+	// timeout covers the statement and hooks but ends before the commit
+	if err := callbacks.Create().After("gorm:begin_transaction").Before("gorm:before_create").Register("listarr:timeout_start", p.start); err != nil {
+		return err
+	}
+	if err := callbacks.Create().Before("gorm:commit_or_rollback_transaction").After("gorm:after_create").Register("listarr:timeout_end", end); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:begin_transaction").Before("gorm:before_update").Register("listarr:timeout_start", p.start); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:commit_or_rollback_transaction").After("gorm:after_update").Register("listarr:timeout_end", end); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:begin_transaction").Before("gorm:before_delete").Register("listarr:timeout_start", p.start); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:commit_or_rollback_transaction").After("gorm:after_delete").Register("listarr:timeout_end", end); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("listarr:timeout_start", p.start); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:after_query").Register("listarr:timeout_end", end); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().Before("gorm:raw").Register("listarr:timeout_start", p.start); err != nil {
+		return err
+	}
+	// Row is left alone: its rows are read after the callbacks return
+	return callbacks.Raw().After("gorm:raw").Register("listarr:timeout_end", end)
+}
+
+func (p queryTimeout) start(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok || ctx.Value(noQueryTimeoutKey{}) != nil {
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	db.Statement.Context = timeoutCtx
+	db.InstanceSet(queryTimeoutCancel, func() {
+		cancel()
+		db.Statement.Context = ctx
+	})
+}
+
+func end(db *gorm.DB) {
+	if restore, ok := db.InstanceGet(queryTimeoutCancel); ok {
+		restore.(func())()
+	}
+}
@@ -26,7 +26,7 @@ const docTemplate = `{
     "paths": {
         "/config": {
             "get": {
-                "description": "Retrieve current application configuration",
+                "description": "Retrieve current application configuration. The ETag header identifies this version of the configuration; send it in If-None-Match to get 304 while it is unchanged, or in If-Match when updating. Requires the admin API key.",
                 "consumes": [
                     "application/json"
                 ],
@@ -37,23 +37,226 @@ const docTemplate = `{
                     "config"
                 ],
                 "summary": "Get configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key (auth.apiKey)",
+                        "name": "X-Api-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ConfigResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_Configuration"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the configuration"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified since the If-None-Match ETag"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.ConfigResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Update application configuration settings in app.config.json",
+                "description": "Update application configuration settings in the active settings store (app.config.json or the database). With If-Match the update only applies if the configuration is unchanged since that ETag was read; http.requireIfMatch makes the header mandatory. Requires the admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Update configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key (auth.apiKey)",
+                        "name": "X-Api-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from GET /config",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Configuration settings",
+                        "name": "configuration",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Configuration"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_Configuration"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated configuration"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/config/integrations/{name}/test": {
+            "post": {
+                "description": "Probe a media server with unsaved settings and report the server name, version and latency, or a categorized failure. caCertFile and ignoreTLSErrors are taken from the saved settings, not the request. Requires the admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Test integration connection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key (auth.apiKey)",
+                        "name": "X-Api-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "emby",
+                            "jellyfin",
+                            "plex",
+                            "navidrome"
+                        ],
+                        "type": "string",
+                        "description": "Integration name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Integration settings, same shape as the matching integrations section of the configuration",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_IntegrationTestResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/config/reset": {
+            "post": {
+                "description": "Reset stored settings to default values. Send an Idempotency-Key to retry safely without resetting twice. Requires the admin API key.",
                 "consumes": [
                     "application/json"
                 ],
@@ -61,66 +264,381 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "config"
+                    "config"
+                ],
+                "summary": "Reset configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key (auth.apiKey)",
+                        "name": "X-Api-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; retries with the same key get the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIResponse-models_Configuration"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/config/schema": {
+            "get": {
+                "description": "Retrieve a JSON Schema describing every configuration setting, its validation rules, examples and defaults. Secret fields are marked with x-secret. The schema is returned as is, without the response envelope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "config"
+                ],
+                "summary": "Get configuration schema",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "description": "Server-sent events stream of changes: config.reloaded, user.created, user.updated, user.deleted and job.progress. Each message has the event type as its event name and the event as JSON data. Reconnecting clients resume with the Last-Event-ID header, or get stream.reset when the missed events are no longer kept. Configuration and user events are only sent to callers with the admin API key, which EventSource clients pass in the apiKey query parameter. A comment is sent every 15 seconds to keep idle connections open.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream events",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Same as Last-Event-ID, for clients that cannot set headers",
+                        "name": "lastEventId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API key, for clients that cannot set X-Api-Key",
+                        "name": "apiKey",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/events/ws": {
+            "get": {
+                "description": "WebSocket alternative to the server-sent events stream at /events with the same events, permissions and resume rules. Each text message is one event as JSON. Pass lastEventId to resume, and apiKey for configuration and user events. The server pings every 15 seconds.",
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream events over WebSocket",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "lastEventId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin API key",
+                        "name": "apiKey",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Report that the process is up and serving requests. Probe responses are not wrapped in the response envelope. Also served without the API prefix at /healthz for container probes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ready": {
+            "get": {
+                "description": "Check the database connection, the loaded configuration and every enabled integration marked as required. Also served without the API prefix at /readyz for container probes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/backup": {
+            "post": {
+                "description": "Download an archive holding every database table as JSON and app.config.json, with the schema version the tables match. Secrets are included, encrypted when a master key is set, unless excludeSecrets is true. Requires the admin API key.",
+                "produces": [
+                    "application/gzip"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Back up the database and configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key (auth.apiKey)",
+                        "name": "X-Api-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave passwords, tokens and API keys out of the archive",
+                        "name": "excludeSecrets",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Backup archive",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "attachment with the archive file name"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/info": {
+            "get": {
+                "description": "Build version and commit, uptime, Go runtime and database pool statistics. Requires the admin API key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
                 ],
-                "summary": "Update configuration",
+                "summary": "System information",
                 "parameters": [
                     {
-                        "description": "Configuration settings",
-                        "name": "configuration",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Configuration"
-                        }
+                        "type": "string",
+                        "description": "Admin API key (auth.apiKey)",
+                        "name": "X-Api-Key",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ConfigResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_SystemInfo"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/models.ConfigResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/models.ConfigResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             }
         },
-        "/config/reset": {
+        "/system/restore": {
             "post": {
-                "description": "Reset app.config.json to default values",
+                "description": "Replace every database table and app.config.json with the content of an archive from POST /system/backup, then reload the configuration. The database is migrated to the schema version of the archive, checked against it, restored, then migrated to the latest version, all in one transaction; archives from a newer schema or another database driver are refused. The db and settings sections of the current configuration are kept, and so are its secrets when the archive has none. Requests made during the restore may fail. Requires the admin API key.",
                 "consumes": [
-                    "application/json"
+                    "application/gzip"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "config"
+                    "system"
+                ],
+                "summary": "Restore a backup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admin API key (auth.apiKey)",
+                        "name": "X-Api-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "format": "binary",
+                        "description": "Backup archive",
+                        "name": "archive",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
                 ],
-                "summary": "Reset configuration",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ConfigResponse"
+                            "$ref": "#/definitions/models.APIResponse-backup_Manifest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.ConfigResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
@@ -128,7 +646,7 @@ const docTemplate = `{
         },
         "/users": {
             "get": {
-                "description": "Get all users in the system",
+                "description": "List users a page at a time. Pages are selected with limit and offset, or with cursor for stable paging through changing data; limit is capped at app.maxPageSize. The Link header points to the first, previous, next and last pages.",
                 "consumes": [
                     "application/json"
                 ],
@@ -139,26 +657,85 @@ const docTemplate = `{
                     "users"
                 ],
                 "summary": "List users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size, at most app.maxPageSize",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Rows to skip, not combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the next link; send it empty to start cursor paging",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Comma separated fields, - for descending: id, name, email",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact email match",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the name",
+                        "name": "name~",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the email",
+                        "name": "email~",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.UserResponse"
+                            "$ref": "#/definitions/models.APIResponse-array_models_UserResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "RFC 8288 links to other pages"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Users matching the filters"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new user in the system",
+                "description": "Create a new user in the system. Send an Idempotency-Key to retry safely: the first response is replayed with Idempotent-Replayed set, and reusing the key for a different body fails with 422.",
                 "consumes": [
                     "application/json"
                 ],
@@ -170,6 +747,12 @@ const docTemplate = `{
                 ],
                 "summary": "Create a new user",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unique key for this request; retries with the same key get the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
                     {
                         "description": "User data",
                         "name": "user",
@@ -184,19 +767,37 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.UserResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_UserResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the new user"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
@@ -204,7 +805,7 @@ const docTemplate = `{
         },
         "/users/{id}": {
             "get": {
-                "description": "Get a user by ID",
+                "description": "Get a user by ID. The ETag header identifies this version of the user; send it in If-None-Match to get 304 while it is unchanged, or in If-Match when updating or deleting.",
                 "consumes": [
                     "application/json"
                 ],
@@ -222,31 +823,52 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UserResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified since the If-None-Match ETag"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Update a user's information",
+                "description": "Update a user's information. With If-Match the update only applies if the user is unchanged since that ETag was read; http.requireIfMatch makes the header mandatory.",
                 "consumes": [
                     "application/json"
                 ],
@@ -265,6 +887,12 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag from GET /users/{id}",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "User data",
                         "name": "user",
@@ -279,31 +907,55 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UserResponse"
+                            "$ref": "#/definitions/models.APIResponse-models_UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the updated user"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete a user by ID",
+                "description": "Delete a user by ID. With If-Match the user is only deleted if unchanged since that ETag was read; http.requireIfMatch makes the header mandatory.",
                 "consumes": [
                     "application/json"
                 ],
@@ -321,22 +973,46 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from GET /users/{id}",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "204": {
                         "description": "No Content"
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/models.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.Problem"
                         }
                     }
                 }
@@ -344,15 +1020,190 @@ const docTemplate = `{
         }
     },
     "definitions": {
-        "models.ConfigResponse": {
-            "description": "Configuration response wrapper",
+        "backup.Manifest": {
+            "type": "object",
+            "properties": {
+                "appVersion": {
+                    "description": "AppVersion is the version of Listarr that made the archive",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "driver": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "integer"
+                },
+                "schemaVersion": {
+                    "description": "SchemaVersion is the last migration applied to the database, which\nthe rows of the tables match",
+                    "type": "integer"
+                },
+                "secrets": {
+                    "description": "Secrets reports whether passwords, tokens and API keys are included",
+                    "type": "boolean"
+                },
+                "tables": {
+                    "description": "Tables counts the rows of each table",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "id": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.APIResponse-array_models_UserResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UserResponse"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Operation successful"
+                },
+                "meta": {
+                    "$ref": "#/definitions/models.PageMeta"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.APIResponse-backup_Manifest": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/backup.Manifest"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Operation successful"
+                },
+                "meta": {
+                    "$ref": "#/definitions/models.PageMeta"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.APIResponse-models_Configuration": {
             "type": "object",
             "properties": {
                 "data": {
                     "$ref": "#/definitions/models.Configuration"
                 },
+                "message": {
+                    "type": "string",
+                    "example": "Operation successful"
+                },
+                "meta": {
+                    "$ref": "#/definitions/models.PageMeta"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.APIResponse-models_IntegrationTestResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.IntegrationTestResult"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Operation successful"
+                },
+                "meta": {
+                    "$ref": "#/definitions/models.PageMeta"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.APIResponse-models_SystemInfo": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SystemInfo"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Operation successful"
+                },
+                "meta": {
+                    "$ref": "#/definitions/models.PageMeta"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.APIResponse-models_UserResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.UserResponse"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Operation successful"
+                },
+                "meta": {
+                    "$ref": "#/definitions/models.PageMeta"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.ComponentStatus": {
+            "description": "Health of a single dependency checked for readiness",
+            "type": "object",
+            "properties": {
                 "error": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "connection refused"
+                },
+                "latencyMs": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "error"
+                    ],
+                    "example": "ok"
                 }
             }
         },
@@ -367,6 +1218,7 @@ const docTemplate = `{
                         "apiBaseURL",
                         "appURL",
                         "environment",
+                        "logFormat",
                         "logLevel",
                         "maxPageSize",
                         "name"
@@ -389,6 +1241,14 @@ const docTemplate = `{
                             ],
                             "example": "development"
                         },
+                        "logFormat": {
+                            "type": "string",
+                            "enum": [
+                                "text",
+                                "json"
+                            ],
+                            "example": "text"
+                        },
                         "logLevel": {
                             "type": "string",
                             "enum": [
@@ -429,6 +1289,10 @@ const docTemplate = `{
                                 "http://localhost:3000"
                             ]
                         },
+                        "apiKey": {
+                            "type": "string",
+                            "example": "your-admin-api-key"
+                        },
                         "enable2FA": {
                             "type": "boolean",
                             "example": false
@@ -453,19 +1317,60 @@ const docTemplate = `{
                         }
                     }
                 },
+                "backup": {
+                    "description": "Backup controls the scheduled backups of the database and configuration",
+                    "type": "object",
+                    "required": [
+                        "dir"
+                    ],
+                    "properties": {
+                        "dir": {
+                            "type": "string",
+                            "example": "config/backups"
+                        },
+                        "excludeSecrets": {
+                            "type": "boolean",
+                            "example": false
+                        },
+                        "interval": {
+                            "type": "integer",
+                            "minimum": 0,
+                            "example": 24
+                        },
+                        "retention": {
+                            "type": "integer",
+                            "minimum": 1,
+                            "example": 7
+                        }
+                    }
+                },
                 "db": {
                     "description": "Database contains database connection settings",
                     "type": "object",
                     "required": [
-                        "host",
+                        "driver",
                         "maxConns",
-                        "name",
-                        "password",
-                        "port",
-                        "timeout",
-                        "user"
+                        "timeout"
                     ],
                     "properties": {
+                        "connMaxIdleTime": {
+                            "type": "integer",
+                            "minimum": 0,
+                            "example": 300
+                        },
+                        "connMaxLifetime": {
+                            "type": "integer",
+                            "minimum": 0,
+                            "example": 1800
+                        },
+                        "driver": {
+                            "type": "string",
+                            "enum": [
+                                "postgres",
+                                "sqlite"
+                            ],
+                            "example": "postgres"
+                        },
                         "host": {
                             "type": "string",
                             "example": "localhost"
@@ -475,6 +1380,15 @@ const docTemplate = `{
                             "minimum": 1,
                             "example": 20
                         },
+                        "maxIdleConns": {
+                            "type": "integer",
+                            "minimum": 0,
+                            "example": 5
+                        },
+                        "migrateOnStart": {
+                            "type": "boolean",
+                            "example": true
+                        },
                         "name": {
                             "type": "string",
                             "example": "listarr"
@@ -483,10 +1397,35 @@ const docTemplate = `{
                             "type": "string",
                             "example": "yourpassword"
                         },
+                        "path": {
+                            "type": "string",
+                            "example": "config/listarr.db"
+                        },
                         "port": {
                             "type": "string",
                             "example": "5432"
                         },
+                        "sslMode": {
+                            "type": "string",
+                            "enum": [
+                                "disable",
+                                "allow",
+                                "prefer",
+                                "require",
+                                "verify-ca",
+                                "verify-full"
+                            ],
+                            "example": "verify-full"
+                        },
+                        "sslRootCert": {
+                            "type": "string",
+                            "example": "/etc/listarr/db-ca.pem"
+                        },
+                        "startupTimeout": {
+                            "type": "integer",
+                            "minimum": 0,
+                            "example": 60
+                        },
                         "timeout": {
                             "type": "integer",
                             "minimum": 1,
@@ -498,6 +1437,20 @@ const docTemplate = `{
                         }
                     }
                 },
+                "frontend": {
+                    "description": "Frontend controls serving the web frontend from this server, read at startup",
+                    "type": "object",
+                    "properties": {
+                        "dir": {
+                            "type": "string",
+                            "example": "/srv/listarr/web"
+                        },
+                        "enabled": {
+                            "type": "boolean",
+                            "example": true
+                        }
+                    }
+                },
                 "http": {
                     "description": "HTTP contains HTTP server configuration",
                     "type": "object",
@@ -505,13 +1458,24 @@ const docTemplate = `{
                         "idleTimeout",
                         "port",
                         "readTimeout",
+                        "shutdownTimeout",
                         "writeTimeout"
                     ],
                     "properties": {
+                        "authRequestsPerMin": {
+                            "type": "integer",
+                            "minimum": 0,
+                            "example": 10
+                        },
                         "enableSSL": {
                             "type": "boolean",
                             "example": false
                         },
+                        "idempotencyWindow": {
+                            "type": "integer",
+                            "minimum": 0,
+                            "example": 24
+                        },
                         "idleTimeout": {
                             "type": "integer",
                             "minimum": 1,
@@ -543,6 +1507,15 @@ const docTemplate = `{
                             "minimum": 0,
                             "example": 100
                         },
+                        "requireIfMatch": {
+                            "type": "boolean",
+                            "example": false
+                        },
+                        "shutdownTimeout": {
+                            "type": "integer",
+                            "minimum": 1,
+                            "example": 30
+                        },
                         "sslCert": {
                             "type": "string",
                             "example": "/path/to/cert.pem"
@@ -551,6 +1524,20 @@ const docTemplate = `{
                             "type": "string",
                             "example": "/path/to/key.pem"
                         },
+                        "trustedProxies": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            },
+                            "example": [
+                                "127.0.0.1",
+                                "10.0.0.0/8"
+                            ]
+                        },
+                        "urlBase": {
+                            "type": "string",
+                            "example": "/listarr"
+                        },
                         "writeTimeout": {
                             "type": "integer",
                             "minimum": 1,
@@ -582,6 +1569,63 @@ const docTemplate = `{
                         }
                     }
                 },
+                "observability": {
+                    "description": "Observability contains tracing settings, read at startup",
+                    "type": "object",
+                    "required": [
+                        "exporter",
+                        "serviceName"
+                    ],
+                    "properties": {
+                        "exporter": {
+                            "type": "string",
+                            "enum": [
+                                "otlp",
+                                "stdout"
+                            ],
+                            "example": "otlp"
+                        },
+                        "otlpEndpoint": {
+                            "type": "string",
+                            "example": "localhost:4318"
+                        },
+                        "otlpInsecure": {
+                            "type": "boolean",
+                            "example": true
+                        },
+                        "sampleRatio": {
+                            "type": "number",
+                            "maximum": 1,
+                            "minimum": 0,
+                            "example": 1
+                        },
+                        "serviceName": {
+                            "type": "string",
+                            "example": "listarr-backend"
+                        },
+                        "tracingEnabled": {
+                            "type": "boolean",
+                            "example": false
+                        }
+                    }
+                },
+                "settings": {
+                    "description": "Settings selects where the mutable settings are stored. Like Db it is a\nbootstrap section and is always read from app.config.json or the environment.",
+                    "type": "object",
+                    "required": [
+                        "backend"
+                    ],
+                    "properties": {
+                        "backend": {
+                            "type": "string",
+                            "enum": [
+                                "file",
+                                "database"
+                            ],
+                            "example": "file"
+                        }
+                    }
+                },
                 "spotdl": {
                     "description": "SpotDL contains Spotify download integration settings",
                     "type": "object",
@@ -731,6 +1775,44 @@ const docTemplate = `{
                 }
             }
         },
+        "models.DBPoolStats": {
+            "description": "Database connection pool statistics",
+            "type": "object",
+            "properties": {
+                "idle": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "inUse": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "maxIdleClosed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "maxLifetimeClosed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "maxOpenConnections": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "openConnections": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "waitCount": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "waitDurationMs": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
         "models.EmbyConfig": {
             "description": "Emby media server configuration",
             "type": "object",
@@ -739,6 +1821,14 @@ const docTemplate = `{
                     "type": "string",
                     "example": "your-api-key"
                 },
+                "bypassProxy": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "caCertFile": {
+                    "type": "string",
+                    "example": "/config/certs/ca.pem"
+                },
                 "enabled": {
                     "type": "boolean",
                     "example": false
@@ -747,10 +1837,18 @@ const docTemplate = `{
                     "type": "string",
                     "example": "localhost"
                 },
+                "ignoreTLSErrors": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "port": {
                     "type": "integer",
                     "example": 8096
                 },
+                "required": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "ssl": {
                     "type": "boolean",
                     "example": false
@@ -761,12 +1859,80 @@ const docTemplate = `{
                 }
             }
         },
-        "models.ErrorResponse": {
+        "models.FieldError": {
+            "description": "A field that failed validation",
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "email"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be a valid email address"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "email"
+                }
+            }
+        },
+        "models.HealthResponse": {
+            "description": "Overall service health with the status of each checked dependency",
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.ComponentStatus"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "unavailable"
+                    ],
+                    "example": "ok"
+                }
+            }
+        },
+        "models.IntegrationTestResult": {
+            "description": "Result of probing an integration with the supplied settings",
             "type": "object",
             "properties": {
                 "error": {
                     "type": "string",
-                    "example": "error message"
+                    "example": "server rejected the credentials"
+                },
+                "failure": {
+                    "type": "string",
+                    "enum": [
+                        "invalid_config",
+                        "dns",
+                        "connection",
+                        "tls",
+                        "timeout",
+                        "auth",
+                        "response"
+                    ],
+                    "example": "auth"
+                },
+                "latencyMs": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "serverName": {
+                    "type": "string",
+                    "example": "Living Room"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "version": {
+                    "type": "string",
+                    "example": "10.8.13"
                 }
             }
         },
@@ -778,6 +1944,14 @@ const docTemplate = `{
                     "type": "string",
                     "example": "your-api-key"
                 },
+                "bypassProxy": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "caCertFile": {
+                    "type": "string",
+                    "example": "/config/certs/ca.pem"
+                },
                 "enabled": {
                     "type": "boolean",
                     "example": false
@@ -786,10 +1960,18 @@ const docTemplate = `{
                     "type": "string",
                     "example": "localhost"
                 },
+                "ignoreTLSErrors": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "port": {
                     "type": "integer",
                     "example": 8096
                 },
+                "required": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "ssl": {
                     "type": "boolean",
                     "example": false
@@ -804,6 +1986,14 @@ const docTemplate = `{
             "description": "Navidrome music server configuration",
             "type": "object",
             "properties": {
+                "bypassProxy": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "caCertFile": {
+                    "type": "string",
+                    "example": "/config/certs/ca.pem"
+                },
                 "enabled": {
                     "type": "boolean",
                     "example": false
@@ -812,6 +2002,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "localhost"
                 },
+                "ignoreTLSErrors": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "password": {
                     "type": "string",
                     "example": "your-password"
@@ -820,6 +2014,10 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 4533
                 },
+                "required": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "ssl": {
                     "type": "boolean",
                     "example": false
@@ -830,10 +2028,40 @@ const docTemplate = `{
                 }
             }
         },
+        "models.PageMeta": {
+            "description": "Paging information for list responses",
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "nextCursor": {
+                    "type": "string",
+                    "example": "eyJzIjoiaWQiLCJ2IjpbNTBdfQ"
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
         "models.PlexConfig": {
             "description": "Plex media server configuration",
             "type": "object",
             "properties": {
+                "bypassProxy": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "caCertFile": {
+                    "type": "string",
+                    "example": "/config/certs/ca.pem"
+                },
                 "enabled": {
                     "type": "boolean",
                     "example": false
@@ -842,10 +2070,18 @@ const docTemplate = `{
                     "type": "string",
                     "example": "localhost"
                 },
+                "ignoreTLSErrors": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "port": {
                     "type": "integer",
                     "example": 32400
                 },
+                "required": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "ssl": {
                     "type": "boolean",
                     "example": false
@@ -856,10 +2092,115 @@ const docTemplate = `{
                 }
             }
         },
+        "models.Problem": {
+            "description": "Error response following RFC 7807 with a stable machine readable code",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "enum": [
+                        "invalid_request",
+                        "validation_failed",
+                        "not_found",
+                        "conflict",
+                        "idempotency_key_reused",
+                        "precondition_failed",
+                        "precondition_required",
+                        "unauthorized",
+                        "forbidden",
+                        "rate_limited",
+                        "payload_too_large",
+                        "config_unavailable",
+                        "internal_error"
+                    ],
+                    "example": "not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "User not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/users/42"
+                },
+                "requestId": {
+                    "type": "string",
+                    "example": "4f1c2a9be0d34e7c9a1b2c3d4e5f6a7b"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:listarr:problem:not_found"
+                }
+            }
+        },
+        "models.RuntimeStats": {
+            "description": "Go runtime statistics",
+            "type": "object",
+            "properties": {
+                "arch": {
+                    "type": "string",
+                    "example": "amd64"
+                },
+                "goVersion": {
+                    "type": "string",
+                    "example": "go1.23.4"
+                },
+                "goroutines": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "heapAllocBytes": {
+                    "type": "integer",
+                    "example": 4194304
+                },
+                "heapSysBytes": {
+                    "type": "integer",
+                    "example": 8388608
+                },
+                "lastGCPauseNs": {
+                    "type": "integer",
+                    "example": 120000
+                },
+                "numCPU": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "numGC": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "os": {
+                    "type": "string",
+                    "example": "linux"
+                },
+                "totalAllocBytes": {
+                    "type": "integer",
+                    "example": 16777216
+                }
+            }
+        },
         "models.SpotifyConfig": {
             "description": "Spotify configuration",
             "type": "object",
             "properties": {
+                "bypassProxy": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "clientId": {
                     "type": "string",
                     "example": "your-client-id"
@@ -882,10 +2223,50 @@ const docTemplate = `{
                 }
             }
         },
+        "models.SystemInfo": {
+            "description": "Build, uptime, runtime and database pool information",
+            "type": "object",
+            "properties": {
+                "buildDate": {
+                    "type": "string",
+                    "example": "2024-01-01T00:00:00Z"
+                },
+                "commit": {
+                    "type": "string",
+                    "example": "3f2c1a9"
+                },
+                "database": {
+                    "$ref": "#/definitions/models.DBPoolStats"
+                },
+                "environment": {
+                    "type": "string",
+                    "example": "production"
+                },
+                "runtime": {
+                    "$ref": "#/definitions/models.RuntimeStats"
+                },
+                "startedAt": {
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00Z"
+                },
+                "uptimeSeconds": {
+                    "type": "integer",
+                    "example": 3600
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.2.0"
+                }
+            }
+        },
         "models.TraktConfig": {
             "description": "Trakt.tv configuration",
             "type": "object",
             "properties": {
+                "bypassProxy": {
+                    "type": "boolean",
+                    "example": false
+                },
                 "clientId": {
                     "type": "string",
                     "example": "your-client-id"
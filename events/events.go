@@ -0,0 +1,198 @@
+// events/events.go
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published by the application
+const (
+	// ConfigReloaded follows every successful configuration reload
+	ConfigReloaded = "config.reloaded"
+	// UserCreated, UserUpdated and UserDeleted carry the user
+	UserCreated = "user.created"
+	UserUpdated = "user.updated"
+	UserDeleted = "user.deleted"
+	// JobProgress reports the progress of a background job as a Progress
+	JobProgress = "job.progress"
+	// StreamReset tells a resuming client that events were missed, for example
+	// after a restart, so it should reload its state
+	StreamReset = "stream.reset"
+)
+
+// DefaultHistory is the number of events kept for Last-Event-ID resumes
+const DefaultHistory = 256
+
+// subscriberBuffer is how many events may wait for a slow subscriber before
+// it is dropped; it resumes from the history when it reconnects
+const subscriberBuffer = 64
+
+// Audience selects who may receive an event
+type Audience int
+
+const (
+	// Everyone delivers the event to every subscriber
+	Everyone Audience = iota
+	// Admins delivers the event only to subscribers with the admin API key
+	Admins
+)
+
+// Event is one published change
+type Event struct {
+	ID       uint64    `json:"id"`
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Data     any       `json:"data,omitempty"`
+	Audience Audience  `json:"-"`
+}
+
+// Progress is the data of JobProgress events
+type Progress struct {
+	Job     string `json:"job"`
+	ID      string `json:"id"`
+	Done    int    `json:"done"`
+	Total   int    `json:"total"`
+	Message string `json:"message,omitempty"`
+}
+
+// Broker is an in-process publish/subscribe hub keeping a bounded history of
+// recent events
+type Broker struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event
+	size        int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// Subscription receives the events allowed for one subscriber. C is closed
+// when the subscription is closed or dropped for falling behind.
+type Subscription struct {
+	C <-chan Event
+
+	ch      chan Event
+	broker  *Broker
+	allowed func(Event) bool
+	closed  bool
+}
+
+// Default is the broker subsystems publish to
+var Default = NewBroker(DefaultHistory)
+
+// Publish sends an event to the default broker
+func Publish(eventType string, audience Audience, data any) Event {
+	return Default.Publish(eventType, audience, data)
+}
+
+// NewBroker creates a broker keeping the last history events
+func NewBroker(history int) *Broker {
+	return &Broker{size: history, subscribers: map[*Subscription]struct{}{}}
+}
+
+// Publish records an event and delivers it to subscribers without blocking
+func (b *Broker) Publish(eventType string, audience Audience, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{ID: b.lastID, Type: eventType, Time: time.Now().UTC(), Data: data, Audience: audience}
+
+	if b.size > 0 {
+		if len(b.history) == b.size {
+			copy(b.history, b.history[1:])
+			b.history = b.history[:b.size-1]
+		}
+		b.history = append(b.history, event)
+	}
+
+	for sub := range b.subscribers {
+		if !sub.allowed(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+	return event
+}
+
+// Subscribe starts receiving events that allowed accepts. A non-zero after
+// resumes after the event with that ID and returns the events since then for
+// replay. When some of them are no longer in the history, replay starts with
+// a StreamReset event instead.
+func (b *Broker) Subscribe(after uint64, allowed func(Event) bool) (*Subscription, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, broker: b, allowed: allowed}
+	if b.closed {
+		sub.closed = true
+		close(ch)
+		return sub, nil
+	}
+	b.subscribers[sub] = struct{}{}
+
+	if after == 0 || after == b.lastID {
+		return sub, nil
+	}
+
+	// IDs restart with the process, so a later ID is from before a restart
+	if after > b.lastID || len(b.history) == 0 || b.history[0].ID > after+1 {
+		return sub, []Event{{ID: b.lastID, Type: StreamReset, Time: time.Now().UTC()}}
+	}
+
+	var replay []Event
+	for _, event := range b.history {
+		if event.ID > after && allowed(event) {
+			replay = append(replay, event)
+		}
+	}
+	return sub, replay
+}
+
+// Close ends every subscription and refuses new ones, so streams finish when
+// the server shuts down
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+// Subscribers returns the number of open subscriptions
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(b.subscribers, sub)
+	close(sub.ch)
+}
+
+// Allow returns a filter for subscribers with or without admin access
+func Allow(admin bool) func(Event) bool {
+	return func(event Event) bool {
+		return event.Audience == Everyone || admin
+	}
+}
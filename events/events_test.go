@@ -0,0 +1,113 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case event, ok := <-sub.C:
+		require.True(t, ok, "subscription closed")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestPublishFiltersByAudience(t *testing.T) {
+	b := NewBroker(10)
+	admin, _ := b.Subscribe(0, Allow(true))
+	everyone, _ := b.Subscribe(0, Allow(false))
+
+	b.Publish(UserCreated, Admins, "alice")
+	b.Publish(JobProgress, Everyone, Progress{Job: "sync", ID: "1", Done: 1, Total: 2})
+
+	assert.Equal(t, UserCreated, receive(t, admin).Type)
+	assert.Equal(t, JobProgress, receive(t, admin).Type)
+
+	event := receive(t, everyone)
+	assert.Equal(t, JobProgress, event.Type)
+	assert.Equal(t, uint64(2), event.ID)
+	assert.Empty(t, everyone.C)
+}
+
+func TestSubscribeReplaysSinceLastID(t *testing.T) {
+	b := NewBroker(10)
+	for i := 0; i < 4; i++ {
+		b.Publish(JobProgress, Everyone, nil)
+	}
+	b.Publish(UserDeleted, Admins, nil)
+
+	_, replay := b.Subscribe(2, Allow(false))
+	require.Len(t, replay, 2)
+	assert.Equal(t, uint64(3), replay[0].ID)
+	assert.Equal(t, uint64(4), replay[1].ID)
+
+	_, replay = b.Subscribe(2, Allow(true))
+	assert.Len(t, replay, 3)
+
+	_, replay = b.Subscribe(5, Allow(true))
+	assert.Empty(t, replay)
+}
+
+func TestSubscribeResetsWhenHistoryIsGone(t *testing.T) {
+	b := NewBroker(2)
+	for i := 0; i < 5; i++ {
+		b.Publish(JobProgress, Everyone, nil)
+	}
+
+	// Events 2 and 3 fell out of the history
+	_, replay := b.Subscribe(1, Allow(false))
+	require.Len(t, replay, 1)
+	assert.Equal(t, StreamReset, replay[0].Type)
+	assert.Equal(t, uint64(5), replay[0].ID)
+
+	_, replay = b.Subscribe(3, Allow(false))
+	assert.Len(t, replay, 2)
+
+	// An ID from before a restart
+	_, replay = b.Subscribe(100, Allow(false))
+	require.Len(t, replay, 1)
+	assert.Equal(t, StreamReset, replay[0].Type)
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	b := NewBroker(0)
+	sub, _ := b.Subscribe(0, Allow(false))
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		b.Publish(JobProgress, Everyone, nil)
+	}
+	assert.Equal(t, 0, b.Subscribers())
+
+	n := 0
+	for range sub.C {
+		n++
+	}
+	assert.Equal(t, subscriberBuffer, n)
+}
+
+func TestClose(t *testing.T) {
+	b := NewBroker(10)
+	sub, _ := b.Subscribe(0, Allow(false))
+	sub.Close()
+	sub.Close()
+	_, open := <-sub.C
+	assert.False(t, open)
+
+	live, _ := b.Subscribe(0, Allow(false))
+	b.Close()
+	_, open = <-live.C
+	assert.False(t, open)
+
+	late, _ := b.Subscribe(0, Allow(false))
+	_, open = <-late.C
+	assert.False(t, open)
+	assert.Equal(t, 0, b.Subscribers())
+}
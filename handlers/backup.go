@@ -0,0 +1,88 @@
+// handlers/backup.go
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"listarr-backend/backup"
+	"listarr-backend/models"
+	"listarr-backend/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxRestoreSize bounds the archive accepted by POST /system/restore
+const maxRestoreSize = 1 << 30
+
+// CreateBackup godoc
+// @Summary Back up the database and configuration
+// @Description Download an archive holding every database table as JSON and app.config.json, with the schema version the tables match. Secrets are included, encrypted when a master key is set, unless excludeSecrets is true. Requires the admin API key.
+// @Tags system
+// @Produce application/gzip
+// @Param X-Api-Key header string true "Admin API key (auth.apiKey)"
+// @Param excludeSecrets query bool false "Leave passwords, tokens and API keys out of the archive"
+// @Success 200 {file} file "Backup archive"
+// @Header 200 {string} Content-Disposition "attachment with the archive file name"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /system/backup [post]
+func CreateBackup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		excludeSecrets, err := strconv.ParseBool(c.DefaultQuery("excludeSecrets", "false"))
+		if err != nil {
+			response.Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, "excludeSecrets must be true or false")
+			return
+		}
+
+		// Buffered so a failure midway is reported instead of a truncated archive
+		var buf bytes.Buffer
+		manifest, err := backup.Create(c.Request.Context(), db, &buf, backup.Options{ExcludeSecrets: excludeSecrets})
+		if err != nil {
+			response.Internal(c, err)
+			return
+		}
+
+		c.Header("Content-Disposition", `attachment; filename="`+backup.FileName(manifest.CreatedAt)+`"`)
+		c.Data(http.StatusOK, backup.ContentType, buf.Bytes())
+	}
+}
+
+// RestoreBackup godoc
+// @Summary Restore a backup
+// @Description Replace every database table and app.config.json with the content of an archive from POST /system/backup, then reload the configuration. The database is migrated to the schema version of the archive, checked against it, restored, then migrated to the latest version, all in one transaction; archives from a newer schema or another database driver are refused. The db and settings sections of the current configuration are kept, and so are its secrets when the archive has none. Requests made during the restore may fail. Requires the admin API key.
+// @Tags system
+// @Accept application/gzip
+// @Produce json
+// @Param X-Api-Key header string true "Admin API key (auth.apiKey)"
+// @Param archive body string true "Backup archive" format(binary)
+// @Success 200 {object} models.APIResponse[backup.Manifest]
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /system/restore [post]
+func RestoreBackup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		archive, err := backup.Read(http.MaxBytesReader(c.Writer, c.Request.Body, maxRestoreSize))
+		if err != nil {
+			response.Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+			return
+		}
+
+		if err := backup.Restore(c.Request.Context(), db, archive); err != nil {
+			var incompatible *backup.IncompatibleError
+			if errors.As(err, &incompatible) {
+				response.Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+				return
+			}
+			response.Internal(c, err)
+			return
+		}
+		response.OK(c, archive.Manifest)
+	}
+}
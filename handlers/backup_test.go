@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"listarr-backend/backup"
+	"listarr-backend/models"
+	"listarr-backend/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	initTestConfig(t)
+	db := openTestDB(t)
+	users := repository.NewGormUsers(db)
+	require.NoError(t, users.Create(context.Background(), &models.User{Name: "Alice", Email: "alice@example.com", Password: "password123"}))
+
+	r := setupTestRouter()
+	r.POST("/system/backup", CreateBackup(db))
+	r.POST("/system/restore", RestoreBackup(db))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/system/backup", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, backup.ContentType, w.Header().Get("Content-Type"))
+	assert.Regexp(t, `^attachment; filename="listarr_backup_\d{8}_\d{6}\.tar\.gz"$`, w.Header().Get("Content-Disposition"))
+	archive := w.Body.Bytes()
+
+	_, err := users.Delete(context.Background(), 1, func(models.User) error { return nil })
+	require.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/system/restore", bytes.NewReader(archive)))
+	require.Equal(t, http.StatusOK, w.Code)
+	var body models.APIResponse[backup.Manifest]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, 1, body.Data.Tables["users"])
+
+	user, err := users.Get(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", user.Email)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/system/restore", strings.NewReader("not an archive")))
+	decodeProblem(t, w, http.StatusBadRequest, models.CodeInvalidRequest)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/system/backup?excludeSecrets=maybe", nil))
+	decodeProblem(t, w, http.StatusBadRequest, models.CodeInvalidRequest)
+}
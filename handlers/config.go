@@ -1,110 +1,146 @@
 package handlers
 
 import (
-	"fmt"
 	"listarr-backend/models"
+	"listarr-backend/response"
 	"listarr-backend/utils"
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
 
+// getConfig returns the loaded configuration, replaced in tests
+var getConfig = utils.GetConfig
+
+// configWriteLock keeps the If-Match check and the save of one configuration
+// update from interleaving with another
+var configWriteLock sync.Mutex
+
+// ifMatchRequired reports whether updates must carry an If-Match header
+func ifMatchRequired() bool {
+	cfg := getConfig()
+	return cfg != nil && cfg.HTTP.RequireIfMatch
+}
+
 // GetConfig godoc
 // @Summary Get configuration
-// @Description Retrieve current application configuration
+// @Description Retrieve current application configuration. The ETag header identifies this version of the configuration; send it in If-None-Match to get 304 while it is unchanged, or in If-Match when updating. Requires the admin API key.
 // @Tags config
 // @Accept json
 // @Produce json
-// @Success 200 {object} models.ConfigResponse
-// @Failure 500 {object} models.ConfigResponse
+// @Param X-Api-Key header string true "Admin API key (auth.apiKey)"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} models.APIResponse[models.Configuration]
+// @Header 200 {string} ETag "Version of the configuration"
+// @Success 304 "Not modified since the If-None-Match ETag"
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /config [get]
 func GetConfig(c *gin.Context) {
-	currentConfig := utils.GetConfig()
+	currentConfig := getConfig()
 	if currentConfig == nil {
-		c.JSON(http.StatusInternalServerError, models.ConfigResponse{
-			Error: "Configuration not initialized",
-		})
+		response.Problem(c, http.StatusInternalServerError, models.CodeConfigUnavailable, "Configuration not initialized")
 		return
 	}
 
-	// Only return the file-based configuration, not environment overrides
-	// fileConfig := utils.GetFileConfig()
-	c.JSON(http.StatusOK, models.ConfigResponse{
-		Data: currentConfig,
-	})
+	if response.NotModified(c, response.ETag(currentConfig)) {
+		return
+	}
+	response.OK(c, currentConfig)
 }
 
 // UpdateConfig godoc
 // @Summary Update configuration
-// @Description Update application configuration settings in app.config.json
+// @Description Update application configuration settings in the active settings store (app.config.json or the database). With If-Match the update only applies if the configuration is unchanged since that ETag was read; http.requireIfMatch makes the header mandatory. Requires the admin API key.
 // @Tags config
 // @Accept json
 // @Produce json
+// @Param X-Api-Key header string true "Admin API key (auth.apiKey)"
+// @Param If-Match header string false "ETag from GET /config"
 // @Param configuration body models.Configuration true "Configuration settings"
-// @Success 200 {object} models.ConfigResponse
-// @Failure 400 {object} models.ConfigResponse
-// @Failure 500 {object} models.ConfigResponse
+// @Success 200 {object} models.APIResponse[models.Configuration]
+// @Header 200 {string} ETag "Version of the updated configuration"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /config [put]
 func UpdateConfig(c *gin.Context) {
 	var newConfig models.Configuration
 	if err := c.ShouldBindJSON(&newConfig); err != nil {
-		c.JSON(http.StatusBadRequest, models.ConfigResponse{
-			Error: "Invalid request body: " + err.Error(),
-		})
+		response.BindError(c, err)
 		return
 	}
 
 	// Validate the new configuration
 	if err := validateConfig(newConfig); err != nil {
-		c.JSON(http.StatusBadRequest, models.ConfigResponse{
-			Error: "Invalid configuration: " + err.Error(),
-		})
+		response.Problem(c, http.StatusBadRequest, models.CodeValidationFailed, "Invalid configuration: "+err.Error())
 		return
 	}
 
-	// Save only to app.config.json
-	if err := utils.SaveFileConfig(newConfig); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ConfigResponse{
-			Error: "Failed to save configuration: " + err.Error(),
-		})
+	configWriteLock.Lock()
+	defer configWriteLock.Unlock()
+
+	if !response.CheckIfMatch(c, response.ETag(getConfig()), ifMatchRequired()) {
 		return
 	}
 
-	// Return the file-based configuration
-	c.JSON(http.StatusOK, models.ConfigResponse{
-		Data: utils.GetFileConfig(),
-	})
+	// Save to the active settings store
+	if err := utils.SaveSettings(newConfig); err != nil {
+		response.Internal(c, err)
+		return
+	}
+
+	// Return the stored configuration, tagged with the version GET now returns
+	c.Header("ETag", response.ETag(getConfig()))
+	response.OK(c, utils.GetStoredConfig())
 }
 
 // ResetConfig godoc
 // @Summary Reset configuration
-// @Description Reset app.config.json to default values
+// @Description Reset stored settings to default values. Send an Idempotency-Key to retry safely without resetting twice. Requires the admin API key.
 // @Tags config
 // @Accept json
 // @Produce json
-// @Success 200 {object} models.ConfigResponse
-// @Failure 500 {object} models.ConfigResponse
+// @Param X-Api-Key header string true "Admin API key (auth.apiKey)"
+// @Param Idempotency-Key header string false "Unique key for this request; retries with the same key get the first response"
+// @Success 200 {object} models.APIResponse[models.Configuration]
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /config/reset [post]
 func ResetConfig(c *gin.Context) {
-	if err := utils.ResetFileConfig(); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ConfigResponse{
-			Error: "Failed to reset configuration: " + err.Error(),
-		})
+	configWriteLock.Lock()
+	defer configWriteLock.Unlock()
+
+	if err := utils.ResetSettings(); err != nil {
+		response.Internal(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, models.ConfigResponse{
-		Data: utils.GetFileConfig(),
-	})
+	response.OK(c, utils.GetStoredConfig())
+}
+
+// GetConfigSchema godoc
+// @Summary Get configuration schema
+// @Description Retrieve a JSON Schema describing every configuration setting, its validation rules, examples and defaults. Secret fields are marked with x-secret. The schema is returned as is, without the response envelope.
+// @Tags config
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /config/schema [get]
+func GetConfigSchema(c *gin.Context) {
+	c.Header("Content-Type", "application/schema+json")
+	c.JSON(http.StatusOK, utils.ConfigSchema())
 }
 
-// validateConfig performs basic validation of configuration values
+// validateConfig checks the configuration as it will apply once saved,
+// including the production safety checks
 func validateConfig(cfg models.Configuration) error {
-	// Add your validation logic here
-	fmt.Println(cfg)
-	// For example:
-	// - Check required fields
-	// - Validate value ranges
-	// - Check format of URLs, etc.
-	return nil
+	return utils.ValidateSettings(cfg)
 }
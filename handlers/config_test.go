@@ -3,13 +3,16 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"listarr-backend/middleware"
 	"listarr-backend/models"
+	"listarr-backend/utils"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Setup test router and any mock dependencies
@@ -19,90 +22,98 @@ func setupTestRouter() *gin.Engine {
 	return r
 }
 
+// initTestConfig loads the configuration, writing defaults to
+// handlers/config on the first run, and returns a copy that passes validation
+func initTestConfig(t *testing.T) *models.Configuration {
+	t.Helper()
+	require.NoError(t, utils.InitConfig())
+	cfg := *utils.GetConfig()
+	// Required settings without a default
+	cfg.Auth.JWTSecret = "test-secret"
+	cfg.SpotDL.DownloadDir = "./downloads"
+	cfg.SpotDL.ConcurrentLimit = 2
+	return &cfg
+}
+
+// decodeProblem checks the response is a problem with the given status and code
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) models.Problem {
+	t.Helper()
+	assert.Equal(t, status, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, status, problem.Status)
+	assert.Equal(t, code, problem.Code)
+	assert.Equal(t, models.ProblemTypePrefix+code, problem.Type)
+	return problem
+}
+
 func TestGetConfig(t *testing.T) {
-	// Setup
+	initTestConfig(t)
+
 	r := setupTestRouter()
 	r.GET("/config", GetConfig)
 
-	// Create a mock request
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/config", nil)
-
-	// Perform the request
 	r.ServeHTTP(w, req)
 
-	// Assert status code
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	// Parse response body
-	var response models.ConfigResponse
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-
-	// Assertions
-	assert.NoError(t, err)
-	assert.NotNil(t, response.Data)
+	var response models.APIResponse[*models.Configuration]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	require.NotNil(t, response.Data)
+	assert.NotEmpty(t, response.Data.App.Environment)
 }
 
 func TestUpdateConfig(t *testing.T) {
-	// Setup
+	testConfig := initTestConfig(t)
+
 	r := setupTestRouter()
 	r.PUT("/config", UpdateConfig)
 
-	// Create test configuration
-	testConfig := models.Configuration{
-		// Fill with test data
-	}
-
-	// Convert to JSON
 	jsonData, _ := json.Marshal(testConfig)
-
-	// Create request with JSON body
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("PUT", "/config", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
-
-	// Perform request
 	r.ServeHTTP(w, req)
 
-	// Assertions
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var response models.ConfigResponse
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	assert.NoError(t, err)
+	var response models.APIResponse[*models.Configuration]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Success)
 	assert.NotNil(t, response.Data)
 }
 
 func TestResetConfig(t *testing.T) {
-	// Setup
+	initTestConfig(t)
+
 	r := setupTestRouter()
 	r.POST("/config/reset", ResetConfig)
 
-	// Create request
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/config/reset", nil)
-
-	// Perform request
 	r.ServeHTTP(w, req)
 
-	// Assertions
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
 func TestGetConfig_Error(t *testing.T) {
-	// Setup
+	getConfig = func() *models.Configuration { return nil }
+	t.Cleanup(func() { getConfig = utils.GetConfig })
+
 	r := setupTestRouter()
 	r.GET("/config", GetConfig)
 
-	// Mock utils.GetConfig to return nil
-	// You'll need to implement mocking here
-
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/config", nil)
-
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	problem := decodeProblem(t, w, http.StatusInternalServerError, models.CodeConfigUnavailable)
+	assert.Equal(t, "/config", problem.Instance)
 }
 
 func TestUpdateConfig_InvalidJSON(t *testing.T) {
@@ -116,27 +127,35 @@ func TestUpdateConfig_InvalidJSON(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	decodeProblem(t, w, http.StatusBadRequest, models.CodeInvalidRequest)
 }
 
 func TestUpdateConfig_ValidationCases(t *testing.T) {
+	base := initTestConfig(t)
+
 	tests := []struct {
 		name          string
-		config        models.Configuration
+		modify        func(cfg *models.Configuration)
 		expectedCode  int
+		expectedField string
 		expectedError string
 	}{
 		{
-			name:   "valid config",
-			config: models.Configuration{
-				// valid configuration
-			},
+			name:         "valid config",
+			modify:       func(cfg *models.Configuration) {},
 			expectedCode: http.StatusOK,
 		},
 		{
-			name:   "invalid config",
-			config: models.Configuration{
-				// invalid configuration
+			name:          "failed binding rule",
+			modify:        func(cfg *models.Configuration) { cfg.App.Environment = "testing" },
+			expectedCode:  http.StatusBadRequest,
+			expectedField: "app.environment",
+		},
+		{
+			name: "insecure production config",
+			modify: func(cfg *models.Configuration) {
+				cfg.App.Environment = "production"
+				cfg.Auth.JWTSecret = "your-secret-key"
 			},
 			expectedCode:  http.StatusBadRequest,
 			expectedError: "Invalid configuration",
@@ -148,7 +167,9 @@ func TestUpdateConfig_ValidationCases(t *testing.T) {
 			r := setupTestRouter()
 			r.PUT("/config", UpdateConfig)
 
-			jsonData, _ := json.Marshal(tt.config)
+			cfg := *base
+			tt.modify(&cfg)
+			jsonData, _ := json.Marshal(cfg)
 			w := httptest.NewRecorder()
 			req, _ := http.NewRequest("PUT", "/config", bytes.NewBuffer(jsonData))
 			req.Header.Set("Content-Type", "application/json")
@@ -156,11 +177,155 @@ func TestUpdateConfig_ValidationCases(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				return
+			}
+
+			problem := decodeProblem(t, w, tt.expectedCode, models.CodeValidationFailed)
+			if tt.expectedField != "" {
+				require.Len(t, problem.Errors, 1)
+				assert.Equal(t, tt.expectedField, problem.Errors[0].Field)
+				assert.Equal(t, "oneof", problem.Errors[0].Rule)
+			}
 			if tt.expectedError != "" {
-				var response models.ConfigResponse
-				json.Unmarshal(w.Body.Bytes(), &response)
-				assert.Contains(t, response.Error, tt.expectedError)
+				assert.Contains(t, problem.Detail, tt.expectedError)
 			}
 		})
 	}
 }
+
+func TestConfigETags(t *testing.T) {
+	testConfig := initTestConfig(t)
+
+	r := setupTestRouter()
+	r.GET("/config", GetConfig)
+	r.PUT("/config", UpdateConfig)
+
+	do := func(method string, headers map[string]string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		if method == "PUT" {
+			jsonData, _ := json.Marshal(testConfig)
+			body = bytes.NewBuffer(jsonData)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/config", body)
+		req.Header.Set("Content-Type", "application/json")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", nil)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// Polling with the current ETag is answered without a body
+	w = do("GET", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// A stale ETag is rejected before anything is saved
+	w = do("PUT", map[string]string{"If-Match": `"stale"`})
+	decodeProblem(t, w, http.StatusPreconditionFailed, models.CodePreconditionFailed)
+
+	w = do("PUT", map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	// Strict mode requires the header
+	strict := *testConfig
+	strict.HTTP.RequireIfMatch = true
+	getConfig = func() *models.Configuration { return &strict }
+	t.Cleanup(func() { getConfig = utils.GetConfig })
+
+	w = do("PUT", nil)
+	decodeProblem(t, w, http.StatusPreconditionRequired, models.CodePreconditionRequired)
+}
+
+func TestGetConfigSchema(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/config/schema", GetConfigSchema)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/config/schema", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/schema+json")
+
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &schema))
+
+	properties := schema["properties"].(map[string]interface{})
+	app := properties["app"].(map[string]interface{})
+	appProps := app["properties"].(map[string]interface{})
+
+	environment := appProps["environment"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"development", "staging", "production"}, environment["enum"])
+
+	maxPageSize := appProps["maxPageSize"].(map[string]interface{})
+	assert.Equal(t, "integer", maxPageSize["type"])
+	assert.Equal(t, float64(1), maxPageSize["minimum"])
+	assert.Equal(t, float64(1000), maxPageSize["maximum"])
+
+	appURL := appProps["appURL"].(map[string]interface{})
+	assert.Equal(t, "uri", appURL["format"])
+	assert.Contains(t, app["required"], "appURL")
+
+	plex := properties["integrations"].(map[string]interface{})["properties"].(map[string]interface{})["plex"].(map[string]interface{})
+	token := plex["properties"].(map[string]interface{})["token"].(map[string]interface{})
+	assert.Equal(t, true, token["x-secret"])
+	assert.Equal(t, []interface{}{"host", "port", "token"}, plex["then"].(map[string]interface{})["required"])
+}
+
+func TestConfig_RequiresAdminKey(t *testing.T) {
+	cfg := initTestConfig(t)
+	cfg.Auth.APIKey = "admin-key"
+	require.NoError(t, utils.SaveSettings(*cfg))
+	t.Cleanup(func() {
+		cfg.Auth.APIKey = ""
+		utils.SaveSettings(*cfg)
+	})
+
+	// Mounted as in main.go
+	admin := middleware.RequireAdmin(utils.GetConfig)
+	r := setupTestRouter()
+	r.GET("/config", admin, GetConfig)
+	r.PUT("/config", admin, UpdateConfig)
+	r.POST("/config/reset", admin, ResetConfig)
+
+	do := func(method, apiKey string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/config", bytes.NewReader(body))
+		if method == "POST" {
+			req.URL.Path = "/config/reset"
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set(middleware.APIKeyHeader, apiKey)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	stolen := *cfg
+	stolen.Auth.APIKey = "stolen-key"
+	body, err := json.Marshal(stolen)
+	require.NoError(t, err)
+
+	for _, apiKey := range []string{"", "wrong-key"} {
+		w := do("GET", apiKey, nil)
+		decodeProblem(t, w, http.StatusUnauthorized, models.CodeUnauthorized)
+		assert.NotContains(t, w.Body.String(), "admin-key")
+
+		decodeProblem(t, do("PUT", apiKey, body), http.StatusUnauthorized, models.CodeUnauthorized)
+		decodeProblem(t, do("POST", apiKey, nil), http.StatusUnauthorized, models.CodeUnauthorized)
+		assert.Equal(t, "admin-key", utils.GetConfig().Auth.APIKey)
+	}
+
+	w := do("GET", "admin-key", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"apiKey":"admin-key"`)
+}
@@ -0,0 +1,225 @@
+// handlers/events.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"listarr-backend/events"
+	"listarr-backend/middleware"
+	"listarr-backend/models"
+	"listarr-backend/response"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// eventsRetry is the reconnect delay suggested to EventSource clients
+	eventsRetry = 3 * time.Second
+	// eventsWriteWait bounds each WebSocket write
+	eventsWriteWait = 10 * time.Second
+)
+
+// eventsHeartbeat is how often idle streams are pinged so proxies keep them
+// open, replaced in tests
+var eventsHeartbeat = 15 * time.Second
+
+var upgrader = websocket.Upgrader{CheckOrigin: websocketOriginAllowed}
+
+// StreamEvents godoc
+// @Summary Stream events
+// @Description Server-sent events stream of changes: config.reloaded, user.created, user.updated, user.deleted and job.progress. Each message has the event type as its event name and the event as JSON data. Reconnecting clients resume with the Last-Event-ID header, or get stream.reset when the missed events are no longer kept. Configuration and user events are only sent to callers with the admin API key, which EventSource clients pass in the apiKey query parameter. A comment is sent every 15 seconds to keep idle connections open.
+// @Tags events
+// @Produce text/event-stream
+// @Param Last-Event-ID header int false "ID of the last event received"
+// @Param lastEventId query int false "Same as Last-Event-ID, for clients that cannot set headers"
+// @Param apiKey query string false "Admin API key, for clients that cannot set X-Api-Key"
+// @Success 200 {object} events.Event
+// @Failure 400 {object} models.Problem
+// @Router /events [get]
+func StreamEvents(broker *events.Broker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, replay, ok := subscribe(c, broker)
+		if !ok {
+			return
+		}
+		defer sub.Close()
+
+		// The stream outlives http.writeTimeout
+		http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		// Stop nginx from buffering the stream
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		fmt.Fprintf(c.Writer, "retry: %d\n\n", eventsRetry.Milliseconds())
+		for _, event := range replay {
+			if writeSSE(c.Writer, event) != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(eventsHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case event, open := <-sub.C:
+				if !open {
+					// Dropped for falling behind or shutting down; the client
+					// reconnects and resumes
+					return
+				}
+				if writeSSE(c.Writer, event) != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// EventsWebSocket godoc
+// @Summary Stream events over WebSocket
+// @Description WebSocket alternative to the server-sent events stream at /events with the same events, permissions and resume rules. Each text message is one event as JSON. Pass lastEventId to resume, and apiKey for configuration and user events. The server pings every 15 seconds.
+// @Tags events
+// @Param lastEventId query int false "ID of the last event received"
+// @Param apiKey query string false "Admin API key"
+// @Success 101 {object} events.Event
+// @Failure 400 {object} models.Problem
+// @Router /events/ws [get]
+func EventsWebSocket(broker *events.Broker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, replay, ok := subscribe(c, broker)
+		if !ok {
+			return
+		}
+		defer sub.Close()
+
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// The upgrader has already answered the request
+			return
+		}
+		defer conn.Close()
+
+		// Clients only send control frames; reading handles pongs and close
+		done := make(chan struct{})
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(2 * eventsHeartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * eventsHeartbeat))
+		})
+		go func() {
+			defer close(done)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		send := func(event events.Event) error {
+			conn.SetWriteDeadline(time.Now().Add(eventsWriteWait))
+			return conn.WriteJSON(event)
+		}
+		for _, event := range replay {
+			if send(event) != nil {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(eventsHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case event, open := <-sub.C:
+				if !open {
+					conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseGoingAway, "resume with lastEventId"),
+						time.Now().Add(eventsWriteWait))
+					return
+				}
+				if send(event) != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventsWriteWait)) != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// subscribe reads the resume position and permissions of an events request.
+// When it reports false the problem response has already been written.
+func subscribe(c *gin.Context, broker *events.Broker) (*events.Subscription, []events.Event, bool) {
+	var after uint64
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("lastEventId")
+	}
+	if lastID != "" {
+		id, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			response.Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, "Last-Event-ID must be an event ID")
+			return nil, nil, false
+		}
+		after = id
+	}
+
+	// EventSource and browser WebSockets cannot set headers
+	apiKey := c.GetHeader(middleware.APIKeyHeader)
+	if apiKey == "" {
+		apiKey = c.Query("apiKey")
+	}
+	admin := middleware.IsAdmin(getConfig(), apiKey)
+
+	sub, replay := broker.Subscribe(after, events.Allow(admin))
+	return sub, replay, true
+}
+
+// writeSSE writes one event in the text/event-stream format
+func writeSSE(w io.Writer, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// websocketOriginAllowed accepts same-origin requests, clients that send no
+// Origin, and the origins allowed by CORS
+func websocketOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	cfg := getConfig()
+	return cfg != nil && middleware.OriginAllowed(cfg, origin)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"listarr-backend/events"
+	"listarr-backend/models"
+	"listarr-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventsTestServer(t *testing.T, broker *events.Broker) *httptest.Server {
+	t.Helper()
+	cfg := models.Configuration{}
+	cfg.Auth.APIKey = "admin-key"
+	getConfig = func() *models.Configuration { return &cfg }
+	t.Cleanup(func() { getConfig = utils.GetConfig })
+
+	r := setupTestRouter()
+	r.GET("/events", StreamEvents(broker))
+	r.GET("/events/ws", EventsWebSocket(broker))
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	t.Cleanup(broker.Close)
+	return srv
+}
+
+// readSSE returns the next message of an event stream, skipping comments
+func readSSE(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+	message := map[string]string{}
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(message) > 0 {
+				return message
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			message["comment"] = strings.TrimSpace(line[1:])
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		message[field] = value
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	broker := events.NewBroker(10)
+	broker.Publish(events.UserCreated, events.Admins, "alice")
+	broker.Publish(events.JobProgress, events.Everyone, events.Progress{Job: "sync", ID: "1", Done: 1, Total: 3})
+	srv := eventsTestServer(t, broker)
+
+	t.Run("replays and streams events for everyone", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", srv.URL+"/events", nil)
+		req.Header.Set("Last-Event-ID", "0")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		body := bufio.NewReader(res.Body)
+		assert.Equal(t, "3000", readSSE(t, body)["retry"])
+
+		for broker.Subscribers() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		broker.Publish(events.UserDeleted, events.Admins, "alice")
+		broker.Publish(events.JobProgress, events.Everyone, events.Progress{Job: "sync", ID: "1", Done: 2, Total: 3})
+
+		message := readSSE(t, body)
+		assert.Equal(t, "4", message["id"])
+		assert.Equal(t, events.JobProgress, message["event"])
+
+		var event struct {
+			Type string          `json:"type"`
+			Data events.Progress `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(message["data"]), &event))
+		assert.Equal(t, events.JobProgress, event.Type)
+		assert.Equal(t, 2, event.Data.Done)
+	})
+
+	t.Run("resumes after Last-Event-ID with admin events", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", srv.URL+"/events?apiKey=admin-key", nil)
+		req.Header.Set("Last-Event-ID", "2")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		body := bufio.NewReader(res.Body)
+		readSSE(t, body)
+		assert.Equal(t, events.UserDeleted, readSSE(t, body)["event"])
+		assert.Equal(t, events.JobProgress, readSSE(t, body)["event"])
+	})
+
+	t.Run("rejects an invalid Last-Event-ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/events?lastEventId=abc", nil)
+		r := setupTestRouter()
+		r.GET("/events", StreamEvents(broker))
+		r.ServeHTTP(w, req)
+
+		decodeProblem(t, w, http.StatusBadRequest, models.CodeInvalidRequest)
+	})
+}
+
+func TestStreamEventsHeartbeat(t *testing.T) {
+	// Set before the server starts and restored after it stops
+	eventsHeartbeat = 10 * time.Millisecond
+	t.Cleanup(func() { eventsHeartbeat = 15 * time.Second })
+	srv := eventsTestServer(t, events.NewBroker(10))
+
+	res, err := http.Get(srv.URL + "/events")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body := bufio.NewReader(res.Body)
+	readSSE(t, body)
+	assert.Equal(t, "heartbeat", readSSE(t, body)["comment"])
+}
+
+func TestEventsWebSocket(t *testing.T) {
+	broker := events.NewBroker(10)
+	broker.Publish(events.UserCreated, events.Admins, "alice")
+	srv := eventsTestServer(t, broker)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/events/ws?lastEventId=0&apiKey=admin-key"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for broker.Subscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	broker.Publish(events.ConfigReloaded, events.Admins, nil)
+
+	var event events.Event
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, events.ConfigReloaded, event.Type)
+	assert.Equal(t, uint64(2), event.ID)
+
+	t.Run("rejects foreign origins", func(t *testing.T) {
+		header := http.Header{"Origin": {"https://evil.example.com"}}
+		_, res, err := websocket.DefaultDialer.Dial(url, header)
+		assert.Error(t, err)
+		require.NotNil(t, res)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+}
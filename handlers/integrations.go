@@ -0,0 +1,65 @@
+// handlers/integrations.go
+package handlers
+
+import (
+	"context"
+	"listarr-backend/integrations"
+	"listarr-backend/models"
+	"listarr-backend/response"
+	"listarr-backend/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// probeTimeout bounds how long a connection test may take end to end
+const probeTimeout = 10 * time.Second
+
+// TestIntegration godoc
+// @Summary Test integration connection
+// @Description Probe a media server with unsaved settings and report the server name, version and latency, or a categorized failure. caCertFile and ignoreTLSErrors are taken from the saved settings, not the request. Requires the admin API key.
+// @Tags config
+// @Accept json
+// @Produce json
+// @Param X-Api-Key header string true "Admin API key (auth.apiKey)"
+// @Param name path string true "Integration name" Enums(emby, jellyfin, plex, navidrome)
+// @Param settings body object true "Integration settings, same shape as the matching integrations section of the configuration"
+// @Success 200 {object} models.APIResponse[models.IntegrationTestResult]
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Router /config/integrations/{name}/test [post]
+func TestIntegration(c *gin.Context) {
+	settings, ok := integrations.NewSettings(c.Param("name"))
+	if !ok {
+		response.NotFound(c, "Unknown integration: "+c.Param("name"))
+		return
+	}
+
+	if err := c.ShouldBindJSON(settings); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	// The probe uses the unsaved host, credentials and proxy bypass, but the
+	// CA bundle and certificate checks of the saved settings, so a request
+	// can neither read files on the server nor turn verification off
+	cfg := utils.GetConfig()
+	opts := integrations.OptionsFor(settings)
+	saved, _ := integrations.SettingsFor(cfg, c.Param("name"))
+	savedOpts := integrations.OptionsFor(saved)
+	opts.CACertFile, opts.IgnoreTLSErrors = savedOpts.CACertFile, savedOpts.IgnoreTLSErrors
+
+	client, err := integrations.NewClient(cfg, opts)
+	if err != nil {
+		response.OK(c, models.IntegrationTestResult{Failure: models.FailureInvalidConfig, Error: err.Error()})
+		return
+	}
+	defer client.CloseIdleConnections()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), probeTimeout)
+	defer cancel()
+
+	response.OK(c, integrations.TestConnection(ctx, client, settings))
+}
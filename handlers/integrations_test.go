@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"listarr-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestIntegration_UsesSavedTLSSettings(t *testing.T) {
+	initTestConfig(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	r := setupTestRouter()
+	r.POST("/config/integrations/:name/test", TestIntegration)
+
+	// Neither setting from the request applies, so the self-signed
+	// certificate is rejected instead of the CA bundle being read
+	body := fmt.Sprintf(`{"host":%q,"port":%s,"apiKey":"key","ssl":true,"ignoreTLSErrors":true,"caCertFile":"/nonexistent/ca.pem"}`,
+		u.Hostname(), u.Port())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/config/integrations/jellyfin/test", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var result models.APIResponse[models.IntegrationTestResult]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, models.FailureTLS, result.Data.Failure)
+	assert.NotContains(t, result.Data.Error, "CA bundle")
+}
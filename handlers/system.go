@@ -0,0 +1,224 @@
+// handlers/system.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"listarr-backend/integrations"
+	"listarr-backend/models"
+	"listarr-backend/response"
+	"listarr-backend/utils"
+	"listarr-backend/version"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// dbPingTimeout bounds the database check of the readiness probe
+	dbPingTimeout = 2 * time.Second
+	// integrationCheckTimeout bounds each integration check of the readiness probe
+	integrationCheckTimeout = 5 * time.Second
+	// integrationCheckTTL is how long integration results are reused, so
+	// frequent probes do not hit the media servers on every call
+	integrationCheckTTL = 30 * time.Second
+)
+
+type cachedCheck struct {
+	status  models.ComponentStatus
+	checked time.Time
+}
+
+var (
+	integrationChecksLock sync.Mutex
+	integrationChecks     = map[string]cachedCheck{}
+)
+
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Report that the process is up and serving requests. Probe responses are not wrapped in the response envelope. Also served without the API prefix at /healthz for container probes.
+// @Tags system
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Router /health [get]
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, models.HealthResponse{Status: models.StatusOK})
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Check the database connection, the loaded configuration and every enabled integration marked as required. Also served without the API prefix at /readyz for container probes.
+// @Tags system
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Failure 503 {object} models.HealthResponse
+// @Router /ready [get]
+func Readyz(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		components := map[string]models.ComponentStatus{
+			"database": checkDatabase(c.Request.Context(), db),
+		}
+
+		cfg := utils.GetConfig()
+		if cfg == nil {
+			components["config"] = models.ComponentStatus{Status: models.StatusError, Error: "configuration not loaded"}
+		} else {
+			components["config"] = models.ComponentStatus{Status: models.StatusOK}
+			for name, status := range checkIntegrations(c.Request.Context(), cfg) {
+				components[name] = status
+			}
+		}
+
+		health := models.HealthResponse{Status: models.StatusOK, Components: components}
+		code := http.StatusOK
+		for _, component := range components {
+			if component.Status != models.StatusOK {
+				health.Status = models.StatusUnavailable
+				code = http.StatusServiceUnavailable
+				break
+			}
+		}
+		c.JSON(code, health)
+	}
+}
+
+// GetSystemInfo godoc
+// @Summary System information
+// @Description Build version and commit, uptime, Go runtime and database pool statistics. Requires the admin API key.
+// @Tags system
+// @Produce json
+// @Param X-Api-Key header string true "Admin API key (auth.apiKey)"
+// @Success 200 {object} models.APIResponse[models.SystemInfo]
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Router /system/info [get]
+func GetSystemInfo(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+
+		info := models.SystemInfo{
+			Version:       version.Version,
+			Commit:        version.CommitHash(),
+			BuildDate:     version.BuildDate,
+			StartedAt:     version.StartTime.UTC().Format(time.RFC3339),
+			UptimeSeconds: int64(version.Uptime().Seconds()),
+			Runtime: models.RuntimeStats{
+				GoVersion:     runtime.Version(),
+				OS:            runtime.GOOS,
+				Arch:          runtime.GOARCH,
+				NumCPU:        runtime.NumCPU(),
+				Goroutines:    runtime.NumGoroutine(),
+				HeapAlloc:     mem.HeapAlloc,
+				HeapSys:       mem.HeapSys,
+				TotalAlloc:    mem.TotalAlloc,
+				NumGC:         mem.NumGC,
+				LastGCPauseNs: mem.PauseNs[(mem.NumGC+255)%256],
+			},
+		}
+		if cfg := utils.GetConfig(); cfg != nil {
+			info.Environment = cfg.App.Environment
+		}
+
+		if db != nil {
+			if sqlDB, err := db.DB(); err == nil {
+				stats := sqlDB.Stats()
+				info.Database = models.DBPoolStats{
+					MaxOpenConnections: stats.MaxOpenConnections,
+					OpenConnections:    stats.OpenConnections,
+					InUse:              stats.InUse,
+					Idle:               stats.Idle,
+					WaitCount:          stats.WaitCount,
+					WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+					MaxIdleClosed:      stats.MaxIdleClosed,
+					MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+				}
+			}
+		}
+
+		response.OK(c, info)
+	}
+}
+
+// checkDatabase pings the connection pool behind db
+func checkDatabase(ctx context.Context, db *gorm.DB) models.ComponentStatus {
+	if db == nil {
+		return models.ComponentStatus{Status: models.StatusError, Error: "database not connected"}
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return models.ComponentStatus{Status: models.StatusError, Error: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errors.New("database ping timed out")
+		}
+		return models.ComponentStatus{Status: models.StatusError, Error: err.Error()}
+	}
+	return models.ComponentStatus{Status: models.StatusOK, LatencyMs: time.Since(start).Milliseconds()}
+}
+
+// checkIntegrations probes the required integrations in parallel, reusing
+// results younger than integrationCheckTTL. The probes are detached from ctx
+// and bounded by integrationCheckTimeout alone, so a caller going away does
+// not cache the integration as down for everyone else.
+func checkIntegrations(ctx context.Context, cfg *models.Configuration) map[string]models.ComponentStatus {
+	results := map[string]models.ComponentStatus{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, name := range integrations.Required(cfg) {
+		integrationChecksLock.Lock()
+		cached, ok := integrationChecks[name]
+		integrationChecksLock.Unlock()
+		if ok && time.Since(cached.checked) < integrationCheckTTL {
+			mu.Lock()
+			results[name] = cached.status
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			status := checkIntegration(context.WithoutCancel(ctx), cfg, name)
+
+			integrationChecksLock.Lock()
+			integrationChecks[name] = cachedCheck{status: status, checked: time.Now()}
+			integrationChecksLock.Unlock()
+
+			mu.Lock()
+			results[name] = status
+			mu.Unlock()
+		}(name)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func checkIntegration(ctx context.Context, cfg *models.Configuration, name string) models.ComponentStatus {
+	settings, _ := integrations.SettingsFor(cfg, name)
+	client, err := integrations.ClientFor(cfg, name)
+	if err != nil {
+		return models.ComponentStatus{Status: models.StatusError, Error: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, integrationCheckTimeout)
+	defer cancel()
+
+	result := integrations.TestConnection(ctx, client, settings)
+	if !result.Success {
+		return models.ComponentStatus{Status: models.StatusError, LatencyMs: result.LatencyMs, Error: result.Error}
+	}
+	return models.ComponentStatus{Status: models.StatusOK, LatencyMs: result.LatencyMs}
+}
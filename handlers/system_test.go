@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"listarr-backend/models"
+	"listarr-backend/version"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthz(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/healthz", Healthz)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestReadyz_ReportsFailingComponents(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/readyz", Readyz(nil))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response models.HealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.StatusUnavailable, response.Status)
+	assert.Equal(t, models.StatusError, response.Components["database"].Status)
+	assert.Contains(t, response.Components, "config")
+}
+
+func TestGetSystemInfo(t *testing.T) {
+	r := setupTestRouter()
+	r.GET("/system/info", GetSystemInfo(nil))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/system/info", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.APIResponse[models.SystemInfo]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	info := response.Data
+	assert.Equal(t, version.Version, info.Version)
+	assert.NotEmpty(t, info.Commit)
+	assert.Equal(t, runtime.Version(), info.Runtime.GoVersion)
+	assert.Positive(t, info.Runtime.Goroutines)
+}
+
+func TestCheckIntegrations_IgnoresCallerCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ServerName":"jelly","Version":"10.9.0"}`))
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	cfg := &models.Configuration{}
+	cfg.Integrations.Jellyfin = models.JellyfinConfig{Enabled: true, Required: true, Host: u.Hostname(), Port: port, APIKey: "key"}
+	t.Cleanup(func() {
+		integrationChecksLock.Lock()
+		delete(integrationChecks, "jellyfin")
+		integrationChecksLock.Unlock()
+	})
+
+	// A probe that gave up must not leave a failure in the cache
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := checkIntegrations(ctx, cfg)
+	assert.Equal(t, models.StatusOK, results["jellyfin"].Status, results["jellyfin"].Error)
+
+	integrationChecksLock.Lock()
+	defer integrationChecksLock.Unlock()
+	assert.Equal(t, models.StatusOK, integrationChecks["jellyfin"].status.Status)
+}
@@ -2,142 +2,269 @@
 package handlers
 
 import (
+	"errors"
+	"listarr-backend/baseurl"
+	"listarr-backend/events"
+	"listarr-backend/listing"
 	"listarr-backend/models"
+	"listarr-backend/repository"
+	"listarr-backend/response"
+	"listarr-backend/utils"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // CreateUser godoc
 //	@Summary		Create a new user
-//	@Description	Create a new user in the system
+//	@Description	Create a new user in the system. Send an Idempotency-Key to retry safely: the first response is replayed with Idempotent-Replayed set, and reusing the key for a different body fails with 422.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			user	body		models.User	true	"User data"
-//	@Success		201		{object}	models.UserResponse
-//	@Failure		400		{object}	models.ErrorResponse
-//	@Failure		500		{object}	models.ErrorResponse
+//	@Param			Idempotency-Key	header		string		false	"Unique key for this request; retries with the same key get the first response"
+//	@Param			user			body		models.User	true	"User data"
+//	@Success		201				{object}	models.APIResponse[models.UserResponse]
+//	@Header			201				{string}	Location	"URL of the new user"
+//	@Failure		400				{object}	models.Problem
+//	@Failure		409				{object}	models.Problem
+//	@Failure		422				{object}	models.Problem
+//	@Failure		500				{object}	models.Problem
 //	@Router			/users [post]
-func CreateUser(db *gorm.DB) gin.HandlerFunc {
+func CreateUser(users repository.Users) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var user models.User
 		if err := c.ShouldBindJSON(&user); err != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			response.BindError(c, err)
 			return
 		}
 
-		result := db.Create(&user)
-		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: result.Error.Error()})
+		if err := users.Create(c.Request.Context(), &user); err != nil {
+			userWriteError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusCreated, user.ToResponse())
+		events.Publish(events.UserCreated, events.Admins, user.ToResponse())
+		c.Header("Location", baseurl.URL(c, c.Request.URL.Path+"/"+strconv.FormatUint(uint64(user.ID), 10)))
+		response.Created(c, user.ToResponse())
 	}
 }
 
+// userListSpec lists the fields GET /users can sort and filter on
+var userListSpec = listing.Spec{
+	Fields: map[string]listing.Field{
+		"id":    {Column: "id", Sortable: true},
+		"name":  {Column: "name", Sortable: true, Contains: true},
+		"email": {Column: "email", Sortable: true, Exact: true, Contains: true},
+	},
+	DefaultSort: "id",
+	KeyColumn:   "id",
+}
+
 // GetUsers godoc
 //	@Summary		List users
-//	@Description	Get all users in the system
+//	@Description	List users a page at a time. Pages are selected with limit and offset, or with cursor for stable paging through changing data; limit is capped at app.maxPageSize. The Link header points to the first, previous, next and last pages.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}		models.UserResponse
-//	@Failure		500	{object}	models.ErrorResponse
+//	@Param			limit	query		int		false	"Page size, at most app.maxPageSize"	default(50)
+//	@Param			offset	query		int		false	"Rows to skip, not combined with cursor"
+//	@Param			cursor	query		string	false	"Cursor from the next link; send it empty to start cursor paging"
+//	@Param			sort	query		string	false	"Comma separated fields, - for descending: id, name, email"	default(id)
+//	@Param			email	query		string	false	"Exact email match"
+//	@Param			name~	query		string	false	"Case-insensitive substring of the name"
+//	@Param			email~	query		string	false	"Case-insensitive substring of the email"
+//	@Success		200	{object}	models.APIResponse[[]models.UserResponse]
+//	@Header			200	{integer}	X-Total-Count	"Users matching the filters"
+//	@Header			200	{string}	Link			"RFC 8288 links to other pages"
+//	@Failure		400	{object}	models.Problem
+//	@Failure		500	{object}	models.Problem
 //	@Router			/users [get]
-func GetUsers(db *gorm.DB) gin.HandlerFunc {
+func GetUsers(users repository.Users) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var users []models.User
-		result := db.Find(&users)
-		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: result.Error.Error()})
+		maxPageSize := 0
+		if cfg := utils.GetConfig(); cfg != nil {
+			maxPageSize = cfg.App.MaxPageSize
+		}
+
+		req, err := listing.Parse(c.Request.URL.Query(), userListSpec, maxPageSize)
+		if err != nil {
+			response.Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+			return
+		}
+
+		page, err := users.List(c.Request.Context(), req)
+		if err != nil {
+			var listErr *listing.Error
+			if errors.As(err, &listErr) {
+				response.Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+				return
+			}
+			response.Internal(c, err)
 			return
 		}
 
-		userResponses := make([]models.UserResponse, len(users))
-		for i, user := range users {
+		userResponses := make([]models.UserResponse, len(page.Items))
+		for i, user := range page.Items {
 			userResponses[i] = user.ToResponse()
 		}
 
-		c.JSON(http.StatusOK, userResponses)
+		listing.WriteHeaders(c, page)
+		response.List(c, userResponses, listing.Meta(page))
 	}
 }
 
 // GetUser godoc
 //	@Summary		Get a user
-//	@Description	Get a user by ID
+//	@Description	Get a user by ID. The ETag header identifies this version of the user; send it in If-None-Match to get 304 while it is unchanged, or in If-Match when updating or deleting.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int	true	"User ID"
-//	@Success		200	{object}	models.UserResponse
-//	@Failure		404	{object}	models.ErrorResponse
-//	@Failure		500	{object}	models.ErrorResponse
+//	@Param			id				path		int		true	"User ID"
+//	@Param			If-None-Match	header		string	false	"ETag from a previous response"
+//	@Success		200				{object}	models.APIResponse[models.UserResponse]
+//	@Header			200				{string}	ETag	"Version of the user"
+//	@Success		304				"Not modified since the If-None-Match ETag"
+//	@Failure		400				{object}	models.Problem
+//	@Failure		404				{object}	models.Problem
+//	@Failure		500				{object}	models.Problem
 //	@Router			/users/{id} [get]
-func GetUser(db *gorm.DB) gin.HandlerFunc {
+func GetUser(users repository.Users) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var user models.User
-		if err := db.First(&user, c.Param("id")).Error; err != nil {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found"})
+		id, ok := userID(c)
+		if !ok {
+			return
+		}
+		user, err := users.Get(c.Request.Context(), id)
+		if err != nil {
+			userError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, user.ToResponse())
+		if response.NotModified(c, response.ETag(user)) {
+			return
+		}
+		response.OK(c, user.ToResponse())
 	}
 }
 
 // UpdateUser godoc
 //	@Summary		Update a user
-//	@Description	Update a user's information
+//	@Description	Update a user's information. With If-Match the update only applies if the user is unchanged since that ETag was read; http.requireIfMatch makes the header mandatory.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			id		path		int			true	"User ID"
-//	@Param			user	body		models.User	true	"User data"
-//	@Success		200		{object}	models.UserResponse
-//	@Failure		400		{object}	models.ErrorResponse
-//	@Failure		404		{object}	models.ErrorResponse
-//	@Failure		500		{object}	models.ErrorResponse
+//	@Param			id			path		int			true	"User ID"
+//	@Param			If-Match	header		string		false	"ETag from GET /users/{id}"
+//	@Param			user		body		models.User	true	"User data"
+//	@Success		200			{object}	models.APIResponse[models.UserResponse]
+//	@Header			200			{string}	ETag	"Version of the updated user"
+//	@Failure		400			{object}	models.Problem
+//	@Failure		404			{object}	models.Problem
+//	@Failure		409			{object}	models.Problem
+//	@Failure		412			{object}	models.Problem
+//	@Failure		428			{object}	models.Problem
+//	@Failure		500			{object}	models.Problem
 //	@Router			/users/{id} [put]
-func UpdateUser(db *gorm.DB) gin.HandlerFunc {
+func UpdateUser(users repository.Users) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var user models.User
-		if err := db.First(&user, c.Param("id")).Error; err != nil {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found"})
+		id, ok := userID(c)
+		if !ok {
 			return
 		}
 
-		if err := c.ShouldBindJSON(&user); err != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		user, err := users.Update(c.Request.Context(), id, func(user *models.User) error {
+			if !response.CheckIfMatch(c, response.ETag(*user), ifMatchRequired()) {
+				return errResponded
+			}
+			// The path names the user, whatever id the body holds
+			if err := c.ShouldBindJSON(user); err != nil {
+				response.BindError(c, err)
+				return errResponded
+			}
+			user.ID = id
+			return nil
+		})
+		if err != nil {
+			userError(c, err)
 			return
 		}
 
-		db.Save(&user)
-		c.JSON(http.StatusOK, user.ToResponse())
+		events.Publish(events.UserUpdated, events.Admins, user.ToResponse())
+		c.Header("ETag", response.ETag(user))
+		response.OK(c, user.ToResponse())
 	}
 }
 
 // DeleteUser godoc
 //	@Summary		Delete a user
-//	@Description	Delete a user by ID
+//	@Description	Delete a user by ID. With If-Match the user is only deleted if unchanged since that ETag was read; http.requireIfMatch makes the header mandatory.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int	true	"User ID"
-//	@Success		204	{object}	nil
-//	@Failure		404	{object}	models.ErrorResponse
-//	@Failure		500	{object}	models.ErrorResponse
+//	@Param			id			path		int		true	"User ID"
+//	@Param			If-Match	header		string	false	"ETag from GET /users/{id}"
+//	@Success		204			{object}	nil
+//	@Failure		400			{object}	models.Problem
+//	@Failure		404			{object}	models.Problem
+//	@Failure		412			{object}	models.Problem
+//	@Failure		428			{object}	models.Problem
+//	@Failure		500			{object}	models.Problem
 //	@Router			/users/{id} [delete]
-func DeleteUser(db *gorm.DB) gin.HandlerFunc {
+func DeleteUser(users repository.Users) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var user models.User
-		if err := db.First(&user, c.Param("id")).Error; err != nil {
-			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "User not found"})
+		id, ok := userID(c)
+		if !ok {
 			return
 		}
 
-		db.Delete(&user)
+		user, err := users.Delete(c.Request.Context(), id, func(user models.User) error {
+			if !response.CheckIfMatch(c, response.ETag(user), ifMatchRequired()) {
+				return errResponded
+			}
+			return nil
+		})
+		if err != nil {
+			userError(c, err)
+			return
+		}
+		events.Publish(events.UserDeleted, events.Admins, user.ToResponse())
 		c.Status(http.StatusNoContent)
 	}
 }
+
+// errResponded aborts a repository write whose problem response has already
+// been written
+var errResponded = errors.New("response already written")
+
+// userID parses the id path parameter. When it reports false the problem
+// response has already been written.
+func userID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, "User ID must be a positive integer")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// userWriteError reports a failed create or update, telling duplicate emails
+// apart from other failures
+func userWriteError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrConflict) {
+		response.Problem(c, http.StatusConflict, models.CodeConflict, "A user with this email already exists")
+		return
+	}
+	response.Internal(c, err)
+}
+
+// userError reports a failed read or write of the user named by the path,
+// unless the response has already been written
+func userError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errResponded):
+	case errors.Is(err, repository.ErrNotFound):
+		response.NotFound(c, "User not found")
+	default:
+		userWriteError(c, err)
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"listarr-backend/database"
+	"listarr-backend/models"
+	"listarr-backend/repository"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// userRepos are the user repositories the handler tests run against: the
+// in-memory one, and the GORM one on a migrated SQLite database, so the
+// handlers also run against real SQL without a database server
+var userRepos = map[string]func(t *testing.T) repository.Users{
+	"memory": func(t *testing.T) repository.Users {
+		return repository.NewMemoryUsers()
+	},
+	"sqlite": func(t *testing.T) repository.Users {
+		return repository.NewGormUsers(openTestDB(t))
+	},
+}
+
+// forEachUserRepo runs test as a subtest against a router on each of
+// userRepos
+func forEachUserRepo(t *testing.T, test func(t *testing.T, r *gin.Engine)) {
+	for name, newRepo := range userRepos {
+		t.Run(name, func(t *testing.T) {
+			test(t, setupUserRouter(newRepo(t)))
+		})
+	}
+}
+
+// openTestDB opens a migrated SQLite database
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := &models.Configuration{}
+	cfg.Db.Driver = "sqlite"
+	cfg.Db.Path = filepath.Join(t.TempDir(), "listarr.db")
+	cfg.Db.MaxConns = 4
+	cfg.Db.MaxIdleConns = 4
+	cfg.Db.Timeout = 5
+	cfg.Db.MigrateOnStart = true
+
+	db, err := database.Open(cfg)
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, database.Prepare(context.Background(), db, cfg))
+	return db
+}
+
+func setupUserRouter(users repository.Users) *gin.Engine {
+	r := setupTestRouter()
+	r.POST("/users", CreateUser(users))
+	r.GET("/users", GetUsers(users))
+	r.GET("/users/:id", GetUser(users))
+	r.PUT("/users/:id", UpdateUser(users))
+	r.DELETE("/users/:id", DeleteUser(users))
+	return r
+}
+
+func sendJSON(r *gin.Engine, method, path string, body any, header http.Header) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeUser(t *testing.T, w *httptest.ResponseRecorder) models.UserResponse {
+	t.Helper()
+	var body models.APIResponse[models.UserResponse]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.True(t, body.Success)
+	return body.Data
+}
+
+func TestCreateUser(t *testing.T) {
+	initTestConfig(t)
+	forEachUserRepo(t, func(t *testing.T, r *gin.Engine) {
+		w := sendJSON(r, "POST", "/users", models.User{Name: "Alice", Email: "alice@example.com", Password: "password123"}, nil)
+		require.Equal(t, http.StatusCreated, w.Code)
+		user := decodeUser(t, w)
+		assert.Equal(t, "Alice", user.Name)
+		assert.NotZero(t, user.ID)
+		assert.Equal(t, "http://example.com/users/1", w.Header().Get("Location"))
+		assert.NotContains(t, w.Body.String(), "password123")
+
+		w = sendJSON(r, "POST", "/users", models.User{Name: "Other", Email: "alice@example.com", Password: "password123"}, nil)
+		decodeProblem(t, w, http.StatusConflict, models.CodeConflict)
+
+		w = sendJSON(r, "POST", "/users", map[string]string{"name": "Bob", "email": "not-an-email"}, nil)
+		problem := decodeProblem(t, w, http.StatusBadRequest, models.CodeValidationFailed)
+		fields := map[string]string{}
+		for _, fieldErr := range problem.Errors {
+			fields[fieldErr.Field] = fieldErr.Rule
+		}
+		assert.Equal(t, map[string]string{"email": "email", "password": "required"}, fields)
+	})
+}
+
+func TestGetUsers(t *testing.T) {
+	initTestConfig(t)
+	forEachUserRepo(t, func(t *testing.T, r *gin.Engine) {
+		for _, name := range []string{"Carol", "alice", "Bob"} {
+			w := sendJSON(r, "POST", "/users", models.User{Name: name, Email: name + "@example.com", Password: "password123"}, nil)
+			require.Equal(t, http.StatusCreated, w.Code)
+		}
+
+		w := sendJSON(r, "GET", "/users?sort=name&limit=2", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body models.APIResponse[[]models.UserResponse]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Data, 2)
+		assert.Equal(t, int64(3), body.Meta.Total)
+		assert.Equal(t, "3", w.Header().Get("X-Total-Count"))
+		assert.Contains(t, w.Header().Get("Link"), `rel="next"`)
+
+		w = sendJSON(r, "GET", "/users?name~=AL", nil, nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Data, 1)
+		assert.Equal(t, "alice", body.Data[0].Name)
+
+		w = sendJSON(r, "GET", "/users?sort=password", nil, nil)
+		decodeProblem(t, w, http.StatusBadRequest, models.CodeInvalidRequest)
+	})
+}
+
+func TestGetUser(t *testing.T) {
+	initTestConfig(t)
+	forEachUserRepo(t, func(t *testing.T, r *gin.Engine) {
+		w := sendJSON(r, "POST", "/users", models.User{Name: "Alice", Email: "alice@example.com", Password: "password123"}, nil)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		w = sendJSON(r, "GET", "/users/1", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "alice@example.com", decodeUser(t, w).Email)
+		etag := w.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		w = sendJSON(r, "GET", "/users/1", nil, http.Header{"If-None-Match": {etag}})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		decodeProblem(t, sendJSON(r, "GET", "/users/2", nil, nil), http.StatusNotFound, models.CodeNotFound)
+		decodeProblem(t, sendJSON(r, "GET", "/users/abc", nil, nil), http.StatusBadRequest, models.CodeInvalidRequest)
+	})
+}
+
+func TestUpdateAndDeleteUser(t *testing.T) {
+	initTestConfig(t)
+	forEachUserRepo(t, func(t *testing.T, r *gin.Engine) {
+		w := sendJSON(r, "POST", "/users", models.User{Name: "Alice", Email: "alice@example.com", Password: "password123"}, nil)
+		require.Equal(t, http.StatusCreated, w.Code)
+		etag := sendJSON(r, "GET", "/users/1", nil, nil).Header().Get("ETag")
+
+		update := models.User{Name: "Alice Smith", Email: "alice@example.com", Password: "password456"}
+		w = sendJSON(r, "PUT", "/users/1", update, http.Header{"If-Match": {etag}})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.UserResponse{ID: 1, Name: "Alice Smith", Email: "alice@example.com"}, decodeUser(t, w))
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+		// The path names the user, not the body
+		other := models.User{ID: 9, Name: "Alice", Email: "alice@example.com", Password: "password456"}
+		w = sendJSON(r, "PUT", "/users/1", other, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(1), decodeUser(t, w).ID)
+
+		w = sendJSON(r, "POST", "/users", models.User{Name: "Bob", Email: "bob@example.com", Password: "password123"}, nil)
+		require.Equal(t, http.StatusCreated, w.Code)
+		w = sendJSON(r, "PUT", "/users/2", models.User{Name: "Bob", Email: "alice@example.com", Password: "password123"}, nil)
+		decodeProblem(t, w, http.StatusConflict, models.CodeConflict)
+
+		// The first ETag no longer matches
+		w = sendJSON(r, "PUT", "/users/1", update, http.Header{"If-Match": {etag}})
+		decodeProblem(t, w, http.StatusPreconditionFailed, models.CodePreconditionFailed)
+		w = sendJSON(r, "DELETE", "/users/1", nil, http.Header{"If-Match": {etag}})
+		decodeProblem(t, w, http.StatusPreconditionFailed, models.CodePreconditionFailed)
+
+		decodeProblem(t, sendJSON(r, "PUT", "/users/7", update, nil), http.StatusNotFound, models.CodeNotFound)
+
+		w = sendJSON(r, "DELETE", "/users/1", nil, nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		decodeProblem(t, sendJSON(r, "GET", "/users/1", nil, nil), http.StatusNotFound, models.CodeNotFound)
+	})
+}
@@ -0,0 +1,221 @@
+// integrations/client.go
+package integrations
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"listarr-backend/metrics"
+	"listarr-backend/models"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// clientTimeout bounds a single request made by an integration client
+const clientTimeout = 30 * time.Second
+
+// ClientOptions are the transport settings of one integration
+type ClientOptions struct {
+	BypassProxy     bool
+	CACertFile      string
+	IgnoreTLSErrors bool
+}
+
+// clientKey identifies a cached client by everything its transport depends on
+type clientKey struct {
+	proxyURL string
+	options  ClientOptions
+}
+
+var (
+	clientsLock sync.Mutex
+	clients     = map[string]cachedClient{}
+)
+
+type cachedClient struct {
+	key    clientKey
+	client *http.Client
+}
+
+// OptionsFor returns the transport settings held by an integration settings struct
+func OptionsFor(settings interface{}) ClientOptions {
+	switch s := settings.(type) {
+	case *models.EmbyConfig:
+		return ClientOptions{BypassProxy: s.BypassProxy, CACertFile: s.CACertFile, IgnoreTLSErrors: s.IgnoreTLSErrors}
+	case *models.JellyfinConfig:
+		return ClientOptions{BypassProxy: s.BypassProxy, CACertFile: s.CACertFile, IgnoreTLSErrors: s.IgnoreTLSErrors}
+	case *models.PlexConfig:
+		return ClientOptions{BypassProxy: s.BypassProxy, CACertFile: s.CACertFile, IgnoreTLSErrors: s.IgnoreTLSErrors}
+	case *models.NavidromeConfig:
+		return ClientOptions{BypassProxy: s.BypassProxy, CACertFile: s.CACertFile, IgnoreTLSErrors: s.IgnoreTLSErrors}
+	case *models.SpotifyConfig:
+		return ClientOptions{BypassProxy: s.BypassProxy}
+	case *models.TraktConfig:
+		return ClientOptions{BypassProxy: s.BypassProxy}
+	}
+	return ClientOptions{}
+}
+
+// SettingsFor returns the configuration section of the named integration
+func SettingsFor(cfg *models.Configuration, name string) (interface{}, bool) {
+	switch name {
+	case "emby":
+		return &cfg.Integrations.Emby, true
+	case "jellyfin":
+		return &cfg.Integrations.Jellyfin, true
+	case "plex":
+		return &cfg.Integrations.Plex, true
+	case "navidrome":
+		return &cfg.Integrations.Navidrome, true
+	case "spotify":
+		return &cfg.Integrations.Spotify, true
+	case "trakt":
+		return &cfg.Integrations.Trakt, true
+	}
+	return nil, false
+}
+
+// ClientFor returns the HTTP client for the named integration. Clients are
+// shared so connections are reused, and rebuilt when the proxy or the
+// integration's transport settings change.
+func ClientFor(cfg *models.Configuration, name string) (*http.Client, error) {
+	settings, ok := SettingsFor(cfg, name)
+	if !ok {
+		return nil, fmt.Errorf("unknown integration %q", name)
+	}
+
+	key := clientKey{options: OptionsFor(settings)}
+	if cfg.HTTP.ProxyEnabled {
+		key.proxyURL = cfg.HTTP.ProxyURL
+	}
+
+	clientsLock.Lock()
+	defer clientsLock.Unlock()
+
+	if cached, ok := clients[name]; ok && cached.key == key {
+		return cached.client, nil
+	}
+
+	client, err := NewClient(cfg, key.options)
+	if err != nil {
+		return nil, err
+	}
+	client.Transport = metrics.InstrumentTransport(name, client.Transport)
+	if old, ok := clients[name]; ok {
+		old.client.CloseIdleConnections()
+	}
+	clients[name] = cachedClient{key: key, client: client}
+	return client, nil
+}
+
+// NewClient builds a traced HTTP client that goes through http.proxyURL when
+// the proxy is enabled, unless opts bypass it. http, https and socks5 proxies are
+// supported. Without a configured proxy the standard HTTP_PROXY and
+// HTTPS_PROXY environment variables apply.
+func NewClient(cfg *models.Configuration, opts ClientOptions) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	switch {
+	case opts.BypassProxy:
+		transport.Proxy = nil
+	case cfg.HTTP.ProxyEnabled:
+		proxyURL, err := parseProxyURL(cfg.HTTP.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	traced := tracedTransport{Transport: otelhttp.NewTransport(transport), base: transport}
+	return &http.Client{Transport: traced, Timeout: clientTimeout}, nil
+}
+
+// tracedTransport records a client span for each request and propagates the
+// trace context, while keeping CloseIdleConnections working
+type tracedTransport struct {
+	*otelhttp.Transport
+	base *http.Transport
+}
+
+func (t tracedTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
+
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	if rawURL == "" {
+		return nil, errors.New("proxy is enabled but http.proxyURL is empty")
+	}
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, use http, https or socks5", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("proxy URL %q has no host", rawURL)
+	}
+	return proxyURL, nil
+}
+
+// newTLSConfig trusts the system roots plus opts.CACertFile, or skips
+// verification entirely when opts.IgnoreTLSErrors is set
+func newTLSConfig(opts ClientOptions) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if opts.IgnoreTLSErrors {
+		tlsConfig.InsecureSkipVerify = true
+		return tlsConfig, nil
+	}
+
+	if opts.CACertFile != "" {
+		pem, err := os.ReadFile(opts.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// Required returns the names of enabled integrations marked as required for
+// readiness
+func Required(cfg *models.Configuration) []string {
+	var names []string
+	integrations := []struct {
+		name              string
+		enabled, required bool
+	}{
+		{"emby", cfg.Integrations.Emby.Enabled, cfg.Integrations.Emby.Required},
+		{"jellyfin", cfg.Integrations.Jellyfin.Enabled, cfg.Integrations.Jellyfin.Required},
+		{"plex", cfg.Integrations.Plex.Enabled, cfg.Integrations.Plex.Required},
+		{"navidrome", cfg.Integrations.Navidrome.Enabled, cfg.Integrations.Navidrome.Required},
+	}
+	for _, integration := range integrations {
+		if integration.enabled && integration.required {
+			names = append(names, integration.name)
+		}
+	}
+	return names
+}
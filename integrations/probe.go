@@ -0,0 +1,279 @@
+// integrations/probe.go
+package integrations
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"listarr-backend/models"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// ServerInfo identifies the server answering a probe
+type ServerInfo struct {
+	Name    string
+	Version string
+}
+
+// ProbeError is a probe failure with its category
+type ProbeError struct {
+	Category string
+	Err      error
+}
+
+func (e *ProbeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// NewSettings returns an empty settings struct for a testable integration
+func NewSettings(name string) (interface{}, bool) {
+	switch name {
+	case "emby":
+		return &models.EmbyConfig{}, true
+	case "jellyfin":
+		return &models.JellyfinConfig{}, true
+	case "plex":
+		return &models.PlexConfig{}, true
+	case "navidrome":
+		return &models.NavidromeConfig{}, true
+	}
+	return nil, false
+}
+
+// TestConnection performs an authenticated request against the server
+// described by settings and reports what it found
+func TestConnection(ctx context.Context, client *http.Client, settings interface{}) models.IntegrationTestResult {
+	start := time.Now()
+
+	var info ServerInfo
+	var err error
+	switch s := settings.(type) {
+	case *models.EmbyConfig:
+		info, err = ProbeEmby(ctx, client, *s)
+	case *models.JellyfinConfig:
+		info, err = ProbeJellyfin(ctx, client, *s)
+	case *models.PlexConfig:
+		info, err = ProbePlex(ctx, client, *s)
+	case *models.NavidromeConfig:
+		info, err = ProbeNavidrome(ctx, client, *s)
+	default:
+		err = &ProbeError{Category: models.FailureInvalidConfig, Err: fmt.Errorf("unsupported integration settings %T", settings)}
+	}
+
+	result := models.IntegrationTestResult{
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Failure = classifyError(err)
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Success = true
+	result.ServerName = info.Name
+	result.Version = info.Version
+	return result
+}
+
+// ProbeEmby reads /System/Info using the Emby API key
+func ProbeEmby(ctx context.Context, client *http.Client, cfg models.EmbyConfig) (ServerInfo, error) {
+	if cfg.APIKey == "" {
+		return ServerInfo{}, invalidConfig("apiKey is required")
+	}
+	return probeSystemInfo(ctx, client, cfg.Host, cfg.Port, cfg.SSL, "X-Emby-Token", cfg.APIKey)
+}
+
+// ProbeJellyfin reads /System/Info using the Jellyfin API key
+func ProbeJellyfin(ctx context.Context, client *http.Client, cfg models.JellyfinConfig) (ServerInfo, error) {
+	if cfg.APIKey == "" {
+		return ServerInfo{}, invalidConfig("apiKey is required")
+	}
+	return probeSystemInfo(ctx, client, cfg.Host, cfg.Port, cfg.SSL,
+		"Authorization", fmt.Sprintf("MediaBrowser Token=%q", cfg.APIKey))
+}
+
+// probeSystemInfo handles the /System/Info endpoint shared by Emby and Jellyfin
+func probeSystemInfo(ctx context.Context, client *http.Client, host string, port int, ssl bool, header, value string) (ServerInfo, error) {
+	endpoint, err := baseURL(host, port, ssl)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+
+	var body struct {
+		ServerName string `json:"ServerName"`
+		Version    string `json:"Version"`
+	}
+	if err := getJSON(ctx, client, endpoint+"/System/Info", map[string]string{header: value}, &body); err != nil {
+		return ServerInfo{}, err
+	}
+	return ServerInfo{Name: body.ServerName, Version: body.Version}, nil
+}
+
+// ProbePlex reads the server root using the Plex token
+func ProbePlex(ctx context.Context, client *http.Client, cfg models.PlexConfig) (ServerInfo, error) {
+	if cfg.Token == "" {
+		return ServerInfo{}, invalidConfig("token is required")
+	}
+	endpoint, err := baseURL(cfg.Host, cfg.Port, cfg.SSL)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+
+	var body struct {
+		MediaContainer struct {
+			FriendlyName string `json:"friendlyName"`
+			Version      string `json:"version"`
+		} `json:"MediaContainer"`
+	}
+	if err := getJSON(ctx, client, endpoint+"/", map[string]string{"X-Plex-Token": cfg.Token}, &body); err != nil {
+		return ServerInfo{}, err
+	}
+	return ServerInfo{Name: body.MediaContainer.FriendlyName, Version: body.MediaContainer.Version}, nil
+}
+
+// ProbeNavidrome calls the Subsonic ping endpoint with token authentication
+func ProbeNavidrome(ctx context.Context, client *http.Client, cfg models.NavidromeConfig) (ServerInfo, error) {
+	if cfg.Username == "" || cfg.Password == "" {
+		return ServerInfo{}, invalidConfig("username and password are required")
+	}
+	endpoint, err := baseURL(cfg.Host, cfg.Port, cfg.SSL)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+
+	saltBytes := make([]byte, 8)
+	if _, err := rand.Read(saltBytes); err != nil {
+		return ServerInfo{}, err
+	}
+	salt := hex.EncodeToString(saltBytes)
+	token := md5.Sum([]byte(cfg.Password + salt))
+
+	query := url.Values{
+		"u": {cfg.Username},
+		"t": {hex.EncodeToString(token[:])},
+		"s": {salt},
+		"v": {"1.16.1"},
+		"c": {"listarr"},
+		"f": {"json"},
+	}
+
+	var body struct {
+		Response struct {
+			Status        string `json:"status"`
+			Type          string `json:"type"`
+			ServerVersion string `json:"serverVersion"`
+			Error         struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"subsonic-response"`
+	}
+	if err := getJSON(ctx, client, endpoint+"/rest/ping.view?"+query.Encode(), nil, &body); err != nil {
+		return ServerInfo{}, err
+	}
+
+	if body.Response.Status != "ok" {
+		category := models.FailureResponse
+		// Subsonic error codes 40-44 are authentication and authorization failures
+		if body.Response.Error.Code >= 40 && body.Response.Error.Code <= 44 {
+			category = models.FailureAuth
+		}
+		return ServerInfo{}, &ProbeError{Category: category, Err: errors.New(body.Response.Error.Message)}
+	}
+
+	return ServerInfo{Name: body.Response.Type, Version: body.Response.ServerVersion}, nil
+}
+
+func baseURL(host string, port int, ssl bool) (string, error) {
+	if host == "" {
+		return "", invalidConfig("host is required")
+	}
+	if port <= 0 || port > 65535 {
+		return "", invalidConfig("port must be between 1 and 65535")
+	}
+
+	scheme := "http"
+	if ssl {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(port))), nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return invalidConfig(err.Error())
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return &ProbeError{Category: models.FailureAuth, Err: fmt.Errorf("server rejected the credentials (%s)", resp.Status)}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return &ProbeError{Category: models.FailureResponse, Err: fmt.Errorf("unexpected response %s", resp.Status)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &ProbeError{Category: models.FailureResponse, Err: fmt.Errorf("error decoding response: %w", err)}
+	}
+	return nil
+}
+
+func invalidConfig(message string) error {
+	return &ProbeError{Category: models.FailureInvalidConfig, Err: errors.New(message)}
+}
+
+// classifyError maps transport errors to the failure categories shown to users
+func classifyError(err error) string {
+	var probeErr *ProbeError
+	if errors.As(err, &probeErr) {
+		return probeErr.Category
+	}
+
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.FailureTimeout
+	case errors.As(err, &dnsErr):
+		return models.FailureDNS
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &unknownAuthority),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidCert):
+		return models.FailureTLS
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return models.FailureTimeout
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.As(err, new(*net.OpError)):
+		return models.FailureConnection
+	}
+	return models.FailureResponse
+}
@@ -0,0 +1,143 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"listarr-backend/models"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hostPort splits a test server URL into the host and port used by settings
+func hostPort(t *testing.T, server *httptest.Server) (string, int) {
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	return u.Hostname(), port
+}
+
+func TestTestConnection_Emby(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/System/Info", r.URL.Path)
+		if r.Header.Get("X-Emby-Token") != "good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"ServerName":"Living Room","Version":"4.8.0"}`))
+	}))
+	defer server.Close()
+	host, port := hostPort(t, server)
+
+	result := TestConnection(context.Background(), server.Client(), &models.EmbyConfig{Host: host, Port: port, APIKey: "good-key"})
+	assert.True(t, result.Success)
+	assert.Equal(t, "Living Room", result.ServerName)
+	assert.Equal(t, "4.8.0", result.Version)
+
+	result = TestConnection(context.Background(), server.Client(), &models.EmbyConfig{Host: host, Port: port, APIKey: "bad-key"})
+	assert.False(t, result.Success)
+	assert.Equal(t, models.FailureAuth, result.Failure)
+}
+
+func TestTestConnection_Jellyfin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `MediaBrowser Token="key"`, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"ServerName":"jelly","Version":"10.9.0"}`))
+	}))
+	defer server.Close()
+	host, port := hostPort(t, server)
+
+	result := TestConnection(context.Background(), server.Client(), &models.JellyfinConfig{Host: host, Port: port, APIKey: "key"})
+	assert.True(t, result.Success)
+	assert.Equal(t, "10.9.0", result.Version)
+}
+
+func TestTestConnection_Plex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("X-Plex-Token"))
+		w.Write([]byte(`{"MediaContainer":{"friendlyName":"plex-box","version":"1.40.0"}}`))
+	}))
+	defer server.Close()
+	host, port := hostPort(t, server)
+
+	result := TestConnection(context.Background(), server.Client(), &models.PlexConfig{Host: host, Port: port, Token: "token"})
+	assert.True(t, result.Success)
+	assert.Equal(t, "plex-box", result.ServerName)
+}
+
+func TestTestConnection_Navidrome(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/ping.view", r.URL.Path)
+		if r.URL.Query().Get("u") != "admin" {
+			w.Write([]byte(`{"subsonic-response":{"status":"failed","error":{"code":40,"message":"Wrong username or password"}}}`))
+			return
+		}
+		w.Write([]byte(`{"subsonic-response":{"status":"ok","type":"navidrome","serverVersion":"0.53.3"}}`))
+	}))
+	defer server.Close()
+	host, port := hostPort(t, server)
+
+	result := TestConnection(context.Background(), server.Client(), &models.NavidromeConfig{Host: host, Port: port, Username: "admin", Password: "pw"})
+	assert.True(t, result.Success)
+	assert.Equal(t, "0.53.3", result.Version)
+
+	result = TestConnection(context.Background(), server.Client(), &models.NavidromeConfig{Host: host, Port: port, Username: "other", Password: "pw"})
+	assert.Equal(t, models.FailureAuth, result.Failure)
+	assert.Equal(t, "Wrong username or password", result.Error)
+}
+
+func TestTestConnection_Failures(t *testing.T) {
+	t.Run("invalid config", func(t *testing.T) {
+		result := TestConnection(context.Background(), http.DefaultClient, &models.PlexConfig{Port: 32400, Token: "token"})
+		assert.Equal(t, models.FailureInvalidConfig, result.Failure)
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+
+		result := TestConnection(context.Background(), http.DefaultClient, &models.PlexConfig{Host: "127.0.0.1", Port: port, Token: "token"})
+		assert.Equal(t, models.FailureConnection, result.Failure)
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+		host, port := hostPort(t, server)
+
+		result := TestConnection(context.Background(), http.DefaultClient, &models.PlexConfig{Host: host, Port: port, Token: "token", SSL: true})
+		assert.Equal(t, models.FailureTLS, result.Failure)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+		defer server.Close()
+		host, port := hostPort(t, server)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		result := TestConnection(ctx, server.Client(), &models.PlexConfig{Host: host, Port: port, Token: "token"})
+		assert.Equal(t, models.FailureTimeout, result.Failure)
+	})
+
+	t.Run("dns", func(t *testing.T) {
+		err := &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "plex.invalid"}}}
+		assert.Equal(t, models.FailureDNS, classifyError(err))
+		assert.Equal(t, models.FailureResponse, classifyError(errors.New("other")))
+	})
+}
@@ -0,0 +1,196 @@
+// listing/find.go
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Page is one page of results
+type Page[T any] struct {
+	Items []T
+	// Total counts every row matching the filters
+	Total int64
+	// NextCursor continues after the last item in cursor mode, empty on the
+	// last page
+	NextCursor string
+	// HasMore reports whether rows follow this page
+	HasMore bool
+	Request Request
+}
+
+// cursor is the position after the last row of a page. Sort is kept so a
+// cursor cannot be replayed against a different order.
+type cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+// Find loads one page of T from db, which may already carry conditions such
+// as tenant scoping
+func Find[T any](db *gorm.DB, req Request) (Page[T], error) {
+	page := Page[T]{Request: req}
+	db = applyFilters(db.Model(new(T)), req.Filters)
+
+	if err := db.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+
+	query := db.Session(&gorm.Session{})
+	if req.UseCursor && req.Cursor != "" {
+		values, err := decodeCursor[T](db, req.Cursor, req)
+		if err != nil {
+			return page, err
+		}
+		query = applyKeyset(query, req.Sort, values)
+	}
+
+	orderBy := make([]clause.OrderByColumn, len(req.Sort))
+	for i, key := range req.Sort {
+		orderBy[i] = clause.OrderByColumn{Column: clause.Column{Name: key.Column}, Desc: key.Desc}
+	}
+	query = query.Clauses(clause.OrderBy{Columns: orderBy})
+
+	if !req.UseCursor {
+		query = query.Offset(req.Offset)
+	}
+	// One extra row tells whether another page follows
+	if err := query.Limit(req.Limit + 1).Find(&page.Items).Error; err != nil {
+		return page, err
+	}
+
+	if len(page.Items) > req.Limit {
+		page.Items = page.Items[:req.Limit]
+		page.HasMore = true
+	}
+
+	if req.UseCursor && page.HasMore {
+		next, err := encodeCursor(db, req, page.Items[len(page.Items)-1])
+		if err != nil {
+			return page, err
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
+
+func applyFilters(db *gorm.DB, filters []Filter) *gorm.DB {
+	for _, filter := range filters {
+		column := db.Statement.Quote(clause.Column{Name: filter.Column})
+		if filter.Contains {
+			db = db.Where("LOWER("+column+") LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(filter.Value))+"%")
+		} else {
+			db = db.Where(column+" = ?", filter.Value)
+		}
+	}
+	return db
+}
+
+// applyKeyset selects the rows after values in the sort order, expanding
+// (a, b) > (x, y) so each column can sort in its own direction
+func applyKeyset(db *gorm.DB, sort []SortKey, values []interface{}) *gorm.DB {
+	var conditions []string
+	var args []interface{}
+
+	for i, key := range sort {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, db.Statement.Quote(clause.Column{Name: sort[j].Column})+" = ?")
+			args = append(args, values[j])
+		}
+		op := ">"
+		if key.Desc {
+			op = "<"
+		}
+		parts = append(parts, db.Statement.Quote(clause.Column{Name: key.Column})+" "+op+" ?")
+		args = append(args, values[i])
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+	return db.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+func encodeCursor[T any](db *gorm.DB, req Request, last T) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&last); err != nil {
+		return "", err
+	}
+
+	row := reflect.ValueOf(&last).Elem()
+	values := make([]interface{}, len(req.Sort))
+	for i, key := range req.Sort {
+		field := stmt.Schema.LookUpField(key.Column)
+		if field == nil {
+			return "", fmt.Errorf("unknown sort column %s", key.Column)
+		}
+		values[i], _ = field.ValueOf(db.Statement.Context, row)
+	}
+
+	return encodeValues(req, values)
+}
+
+// decodeCursor reads the values of a cursor into the Go types of the sort
+// columns of T, so they compare correctly in every database
+func decodeCursor[T any](db *gorm.DB, raw string, req Request) ([]interface{}, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+
+	types := make([]reflect.Type, len(req.Sort))
+	for i, key := range req.Sort {
+		field := stmt.Schema.LookUpField(key.Column)
+		if field == nil {
+			return nil, fmt.Errorf("unknown sort column %s", key.Column)
+		}
+		types[i] = field.FieldType
+	}
+	return decodeValues(raw, req, types)
+}
+
+// encodeValues makes a cursor continuing after a row with the given sort
+// column values
+func encodeValues(req Request, values []interface{}) (string, error) {
+	data, err := json.Marshal(cursor{Sort: req.sortString, Values: values})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeValues reads the values of a cursor as the given types
+func decodeValues(raw string, req Request, types []reflect.Type) ([]interface{}, error) {
+	invalid := &Error{Param: "cursor", Message: "malformed or from a different sort order"}
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var c struct {
+		Sort   string            `json:"s"`
+		Values []json.RawMessage `json:"v"`
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != req.sortString || len(c.Values) != len(types) {
+		return nil, invalid
+	}
+
+	values := make([]interface{}, len(types))
+	for i, t := range types {
+		value := reflect.New(t)
+		if err := json.Unmarshal(c.Values[i], value.Interface()); err != nil {
+			return nil, invalid
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
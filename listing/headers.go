@@ -0,0 +1,73 @@
+// listing/headers.go
+package listing
+
+import (
+	"fmt"
+	"listarr-backend/baseurl"
+	"listarr-backend/models"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TotalCountHeader carries the number of rows matching the filters
+const TotalCountHeader = "X-Total-Count"
+
+// WriteHeaders sets X-Total-Count and an RFC 8288 Link header with first,
+// prev, next and last pages, or only next when paging with cursors
+func WriteHeaders[T any](c *gin.Context, page Page[T]) {
+	c.Header(TotalCountHeader, strconv.FormatInt(page.Total, 10))
+
+	req := page.Request
+	path := baseurl.Path(c, c.Request.URL.Path)
+	var links []string
+	link := func(rel string, set map[string]string) {
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", pageURL(path, c.Request.URL.Query(), req.Limit, set), rel))
+	}
+
+	if req.UseCursor {
+		if page.NextCursor != "" {
+			link("next", map[string]string{"cursor": page.NextCursor})
+		}
+	} else {
+		offset := func(n int) map[string]string { return map[string]string{"offset": strconv.Itoa(n)} }
+
+		link("first", offset(0))
+		if req.Offset > 0 {
+			link("prev", offset(max(req.Offset-req.Limit, 0)))
+		}
+		if page.HasMore {
+			link("next", offset(req.Offset+req.Limit))
+		}
+		if page.Total > 0 {
+			link("last", offset(int((page.Total-1)/int64(req.Limit))*req.Limit))
+		}
+	}
+
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
+
+// Meta describes page for the meta field of the response envelope
+func Meta[T any](page Page[T]) models.PageMeta {
+	meta := models.PageMeta{Total: page.Total, Limit: page.Request.Limit, NextCursor: page.NextCursor}
+	if !page.Request.UseCursor {
+		meta.Offset = page.Request.Offset
+	}
+	return meta
+}
+
+// pageURL returns path with the request query, replacing the paging
+// parameters and keeping sort and filters
+func pageURL(path string, query url.Values, limit int, set map[string]string) string {
+	query.Del("offset")
+	query.Del("cursor")
+	query.Set("limit", strconv.Itoa(limit))
+	for key, value := range set {
+		query.Set(key, value)
+	}
+	return path + "?" + query.Encode()
+}
@@ -0,0 +1,188 @@
+// listing/listing.go
+package listing
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultPageSize is used when a request does not set limit
+const DefaultPageSize = 50
+
+// Field describes how a list endpoint exposes one column
+type Field struct {
+	// Column is the database column behind the field
+	Column string
+	// Sortable allows sort=field and sort=-field
+	Sortable bool
+	// Exact allows field=value filters
+	Exact bool
+	// Contains allows case-insensitive field~=value filters
+	Contains bool
+}
+
+// Spec lists the fields a list endpoint accepts for sorting and filtering.
+// Fields not in the spec are rejected, so only indexed or cheap columns
+// should be added.
+type Spec struct {
+	Fields map[string]Field
+	// DefaultSort applies when the request has no sort, as in "-createdAt"
+	DefaultSort string
+	// KeyColumn is a unique column appended to every sort so pages are stable
+	// and cursors are unambiguous
+	KeyColumn string
+}
+
+// Request is a parsed list request
+type Request struct {
+	Limit  int
+	Offset int
+	// Cursor is set when the client pages with cursors; an empty cursor
+	// parameter starts from the first page
+	Cursor     string
+	UseCursor  bool
+	Sort       []SortKey
+	Filters    []Filter
+	sortString string
+}
+
+// SortKey is one column of the sort order
+type SortKey struct {
+	Column string
+	Desc   bool
+}
+
+// Filter restricts results to rows where Column matches Value
+type Filter struct {
+	Column   string
+	Value    string
+	Contains bool
+}
+
+// Error is a problem with the list parameters, reported to clients as a 400
+type Error struct {
+	Param   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Param, e.Message)
+}
+
+// Parse reads limit, offset, cursor, sort and filter parameters. Limits above
+// maxPageSize are lowered to it.
+func Parse(values url.Values, spec Spec, maxPageSize int) (Request, error) {
+	req := Request{Limit: DefaultPageSize}
+	if maxPageSize > 0 && req.Limit > maxPageSize {
+		req.Limit = maxPageSize
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return req, &Error{Param: "limit", Message: "must be a positive integer"}
+		}
+		req.Limit = limit
+		if maxPageSize > 0 && limit > maxPageSize {
+			req.Limit = maxPageSize
+		}
+	}
+
+	if raw := values.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return req, &Error{Param: "offset", Message: "must be zero or a positive integer"}
+		}
+		req.Offset = offset
+	}
+
+	if _, ok := values["cursor"]; ok {
+		if req.Offset > 0 {
+			return req, &Error{Param: "cursor", Message: "cannot be combined with offset"}
+		}
+		req.UseCursor = true
+		req.Cursor = values.Get("cursor")
+	}
+
+	order := values.Get("sort")
+	if order == "" {
+		order = spec.DefaultSort
+	}
+	if err := req.parseSort(order, spec); err != nil {
+		return req, err
+	}
+
+	if err := req.parseFilters(values, spec); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// parseSort reads a comma separated list of fields, each optionally
+// prefixed with - for descending order, and appends the key column
+func (r *Request) parseSort(sort string, spec Spec) error {
+	var names []string
+	hasKey := false
+
+	for _, name := range strings.Split(sort, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+
+		field, ok := spec.Fields[name]
+		if !ok || !field.Sortable {
+			return &Error{Param: "sort", Message: fmt.Sprintf("cannot sort by %q", name)}
+		}
+		r.Sort = append(r.Sort, SortKey{Column: field.Column, Desc: desc})
+		if field.Column == spec.KeyColumn {
+			hasKey = true
+		}
+		if desc {
+			name = "-" + name
+		}
+		names = append(names, name)
+	}
+
+	if !hasKey && spec.KeyColumn != "" {
+		r.Sort = append(r.Sort, SortKey{Column: spec.KeyColumn})
+	}
+	r.sortString = strings.Join(names, ",")
+	return nil
+}
+
+// parseFilters reads field=value and field~=value parameters for fields in
+// the spec
+func (r *Request) parseFilters(values url.Values, spec Spec) error {
+	for param, vals := range values {
+		name, contains := strings.CutSuffix(param, "~")
+		field, ok := spec.Fields[name]
+		if !ok {
+			// Other parameters such as limit are not filters
+			if contains {
+				return &Error{Param: param, Message: "unknown filter"}
+			}
+			continue
+		}
+		if (contains && !field.Contains) || (!contains && !field.Exact) {
+			return &Error{Param: param, Message: "filter not supported"}
+		}
+		for _, value := range vals {
+			r.Filters = append(r.Filters, Filter{Column: field.Column, Value: value, Contains: contains})
+		}
+	}
+
+	// Map order is random; a stable order keeps generated queries identical
+	sort.Slice(r.Filters, func(i, j int) bool {
+		if r.Filters[i].Column != r.Filters[j].Column {
+			return r.Filters[i].Column < r.Filters[j].Column
+		}
+		return r.Filters[i].Value < r.Filters[j].Value
+	})
+	return nil
+}
@@ -0,0 +1,179 @@
+package listing
+
+import (
+	"fmt"
+	"listarr-backend/database/dbtest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID    uint `gorm:"primarykey"`
+	Name  string
+	Email string
+}
+
+var itemSpec = Spec{
+	Fields: map[string]Field{
+		"id":    {Column: "id", Sortable: true},
+		"name":  {Column: "name", Sortable: true, Contains: true},
+		"email": {Column: "email", Sortable: true, Exact: true},
+	},
+	DefaultSort: "id",
+	KeyColumn:   "id",
+}
+
+func testDB(t *testing.T) *gorm.DB {
+	db := dbtest.Open(t)
+	require.NoError(t, db.AutoMigrate(&item{}))
+
+	names := []string{"Bea", "al", "Cy", "Al_x", "bo", "Al"}
+	for i, name := range names {
+		require.NoError(t, db.Create(&item{Name: name, Email: fmt.Sprintf("u%d@example.com", i+1)}).Error)
+	}
+	return db
+}
+
+func parse(t *testing.T, query string, maxPageSize int) Request {
+	values, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	req, err := Parse(values, itemSpec, maxPageSize)
+	require.NoError(t, err)
+	return req
+}
+
+func names(items []item) []string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.Name)
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	req := parse(t, "limit=500&sort=-name,email", 100)
+	assert.Equal(t, 100, req.Limit)
+	assert.Equal(t, []SortKey{{Column: "name", Desc: true}, {Column: "email"}, {Column: "id"}}, req.Sort)
+
+	assert.Equal(t, 20, parse(t, "", 20).Limit)
+	assert.Equal(t, DefaultPageSize, parse(t, "", 0).Limit)
+
+	for _, query := range []string{"limit=0", "offset=-1", "sort=password", "name=exact", "email~=partial", "nope~=x", "cursor=&offset=5"} {
+		values, _ := url.ParseQuery(query)
+		_, err := Parse(values, itemSpec, 100)
+		var listErr *Error
+		assert.ErrorAs(t, err, &listErr, query)
+	}
+}
+
+func TestFind_OffsetSortAndFilters(t *testing.T) {
+	db := testDB(t)
+
+	page, err := Find[item](db, parse(t, "limit=2&offset=2&sort=-id", 100))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Al_x", "Cy"}, names(page.Items))
+	assert.Equal(t, int64(6), page.Total)
+	assert.True(t, page.HasMore)
+
+	// Contains is case-insensitive and treats LIKE wildcards literally
+	page, err = Find[item](db, parse(t, "name~=AL", 100))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"al", "Al_x", "Al"}, names(page.Items))
+
+	page, err = Find[item](db, parse(t, "name~=l_", 100))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Al_x"}, names(page.Items))
+	assert.Equal(t, int64(1), page.Total)
+
+	page, err = Find[item](db, parse(t, "email=u3@example.com", 100))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Cy"}, names(page.Items))
+}
+
+func TestFind_CursorWalksEveryRowOnce(t *testing.T) {
+	db := testDB(t)
+	// Duplicate names make the id tiebreaker matter
+	require.NoError(t, db.Create(&item{Name: "Cy", Email: "u7@example.com"}).Error)
+
+	var seen []uint
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := Find[item](db, parse(t, "limit=3&sort=-name&cursor="+url.QueryEscape(cursor), 100))
+		require.NoError(t, err)
+		for _, it := range page.Items {
+			seen = append(seen, it.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	// Byte order puts lower case names first when descending
+	assert.Equal(t, []uint{5, 2, 3, 7, 1, 4, 6}, seen)
+
+	// A cursor only works with the sort it was made for
+	page, err := Find[item](db, parse(t, "limit=3&sort=-name&cursor=", 100))
+	require.NoError(t, err)
+	_, err = Find[item](db, parse(t, "limit=3&sort=name&cursor="+url.QueryEscape(page.NextCursor), 100))
+	var listErr *Error
+	assert.ErrorAs(t, err, &listErr)
+}
+
+func TestFindSlice_MatchesFind(t *testing.T) {
+	db := testDB(t)
+	require.NoError(t, db.Create(&item{Name: "Cy", Email: "u7@example.com"}).Error)
+	var all []item
+	require.NoError(t, db.Find(&all).Error)
+
+	queries := []string{
+		"",
+		"limit=2&offset=2&sort=-id",
+		"offset=10",
+		"name~=AL",
+		"name~=l_",
+		"email=u3@example.com",
+		"limit=3&sort=-name&cursor=",
+		"limit=2&sort=name,-email&cursor=",
+	}
+	for _, query := range queries {
+		req := parse(t, query, 100)
+		want, err := Find[item](db, req)
+		require.NoError(t, err, query)
+		got, err := FindSlice(all, req)
+		require.NoError(t, err, query)
+		assert.Equal(t, want, got, query)
+
+		// Cursors made in memory continue the same way in the database
+		if got.NextCursor != "" {
+			next := parse(t, query+url.QueryEscape(got.NextCursor), 100)
+			want, err := Find[item](db, next)
+			require.NoError(t, err, query)
+			got, err := FindSlice(all, next)
+			require.NoError(t, err, query)
+			assert.Equal(t, want, got, query)
+		}
+	}
+}
+
+func TestWriteHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/api/v1/users?limit=2&offset=2&name~=a", nil)
+
+	req := parse(t, "limit=2&offset=2&name~=a", 100)
+	WriteHeaders(c, Page[item]{Total: 7, HasMore: true, Request: req})
+
+	assert.Equal(t, "7", w.Header().Get(TotalCountHeader))
+	assert.Equal(t, `</api/v1/users?limit=2&name~=a&offset=0>; rel="first", `+
+		`</api/v1/users?limit=2&name~=a&offset=0>; rel="prev", `+
+		`</api/v1/users?limit=2&name~=a&offset=4>; rel="next", `+
+		`</api/v1/users?limit=2&name~=a&offset=6>; rel="last"`, w.Header().Get("Link"))
+}
@@ -0,0 +1,149 @@
+// listing/slice.go
+package listing
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// schemaCache holds the parsed schemas of the types listed by FindSlice
+var schemaCache sync.Map
+
+// FindSlice selects one page of items the way Find does in the database,
+// using the GORM column names of T, so in-memory stores answer list
+// requests exactly like their database counterparts
+func FindSlice[T any](items []T, req Request) (Page[T], error) {
+	page := Page[T]{Request: req}
+	s, err := schema.Parse(new(T), &schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return page, err
+	}
+
+	fields := make([]*schema.Field, len(req.Sort))
+	types := make([]reflect.Type, len(req.Sort))
+	for i, key := range req.Sort {
+		if fields[i] = s.LookUpField(key.Column); fields[i] == nil {
+			return page, fmt.Errorf("unknown sort column %s", key.Column)
+		}
+		types[i] = fields[i].FieldType
+	}
+	// values returns the sort column values of item
+	values := func(item *T) []interface{} {
+		row := reflect.ValueOf(item).Elem()
+		out := make([]interface{}, len(fields))
+		for i, field := range fields {
+			out[i], _ = field.ValueOf(context.Background(), row)
+		}
+		return out
+	}
+
+	var matched []T
+	for _, item := range items {
+		ok, err := matchFilters(s, &item, req.Filters)
+		if err != nil {
+			return page, err
+		}
+		if ok {
+			matched = append(matched, item)
+		}
+	}
+	page.Total = int64(len(matched))
+
+	slices.SortStableFunc(matched, func(a, b T) int {
+		return compareKeys(req.Sort, values(&a), values(&b))
+	})
+
+	start := min(req.Offset, len(matched))
+	if req.UseCursor {
+		start = 0
+		if req.Cursor != "" {
+			after, err := decodeValues(req.Cursor, req, types)
+			if err != nil {
+				return page, err
+			}
+			start = len(matched)
+			for i := range matched {
+				if compareKeys(req.Sort, values(&matched[i]), after) > 0 {
+					start = i
+					break
+				}
+			}
+		}
+	}
+
+	end := min(start+req.Limit, len(matched))
+	page.Items = matched[start:end]
+	page.HasMore = end < len(matched)
+
+	if req.UseCursor && page.HasMore {
+		next, err := encodeValues(req, values(&page.Items[len(page.Items)-1]))
+		if err != nil {
+			return page, err
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
+
+func matchFilters[T any](s *schema.Schema, item *T, filters []Filter) (bool, error) {
+	row := reflect.ValueOf(item).Elem()
+	for _, filter := range filters {
+		field := s.LookUpField(filter.Column)
+		if field == nil {
+			return false, fmt.Errorf("unknown filter column %s", filter.Column)
+		}
+		value, _ := field.ValueOf(context.Background(), row)
+		text := fmt.Sprint(value)
+
+		if filter.Contains {
+			if !strings.Contains(strings.ToLower(text), strings.ToLower(filter.Value)) {
+				return false, nil
+			}
+		} else if text != filter.Value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compareKeys orders two rows by their sort column values
+func compareKeys(sort []SortKey, a, b []interface{}) int {
+	for i, key := range sort {
+		c := compareValues(a[i], b[i])
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareValues(a, b interface{}) int {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case va.CanInt() && vb.CanInt():
+		return cmp.Compare(va.Int(), vb.Int())
+	case va.CanUint() && vb.CanUint():
+		return cmp.Compare(va.Uint(), vb.Uint())
+	case va.CanFloat() && vb.CanFloat():
+		return cmp.Compare(va.Float(), vb.Float())
+	case va.Kind() == reflect.String && vb.Kind() == reflect.String:
+		return cmp.Compare(va.String(), vb.String())
+	}
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb)
+		}
+	}
+	return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
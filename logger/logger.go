@@ -0,0 +1,113 @@
+// logger/logger.go
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Formats accepted by SetFormat
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type contextKey struct{}
+
+var (
+	level = new(slog.LevelVar)
+
+	mu     sync.Mutex
+	out    io.Writer = os.Stderr
+	format           = FormatText
+)
+
+func init() {
+	rebuild()
+}
+
+// rebuild installs a new default logger for the current output and format.
+// Callers other than init must hold mu.
+func rebuild() {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(out, opts)
+	} else {
+		handler = slog.NewTextHandler(out, opts)
+	}
+	slog.SetDefault(slog.New(&scrubHandler{next: handler}))
+}
+
+// ParseLevel converts a configured log level, debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return l, fmt.Errorf("unknown log level %q", name)
+	}
+	return l, nil
+}
+
+// SetLevel changes the minimum level of every logger, including ones
+// already derived from the default logger
+func SetLevel(name string) error {
+	l, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// Level returns the current minimum level
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetFormat switches the output between text and json
+func SetFormat(name string) error {
+	name = strings.ToLower(name)
+	if name != FormatText && name != FormatJSON {
+		return fmt.Errorf("unknown log format %q", name)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if name != format {
+		format = name
+		rebuild()
+	}
+	return nil
+}
+
+// SetOutput changes where logs are written, nil restores stderr
+func SetOutput(w io.Writer) {
+	if w == nil {
+		w = os.Stderr
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+	rebuild()
+}
+
+// NewContext returns a copy of ctx carrying l
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, such as the request logger
+// with its request ID, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
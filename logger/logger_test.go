@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capture sends JSON logs to a buffer for the duration of the test
+func capture(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	require.NoError(t, SetFormat(FormatJSON))
+	SetOutput(&buf)
+	t.Cleanup(func() {
+		SetOutput(nil)
+		SetSecrets(nil)
+		SetLevel("info")
+	})
+	return &buf
+}
+
+func TestScrub_SensitiveKeysAndSecretValues(t *testing.T) {
+	buf := capture(t)
+	SetSecrets([]string{"hunter22", "abc"})
+
+	slog.Info("connecting with hunter22",
+		"password", "plain",
+		"apiKey", "key-123",
+		"dsn", "user=listarr password=hunter22",
+		"err", errors.New("auth failed for hunter22"),
+		slog.Group("db", "Password", "nested"),
+		"short", "abc")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "connecting with "+Redacted, entry["msg"])
+	assert.Equal(t, Redacted, entry["password"])
+	assert.Equal(t, Redacted, entry["apiKey"])
+	assert.Equal(t, "user=listarr password="+Redacted, entry["dsn"])
+	assert.Equal(t, "auth failed for "+Redacted, entry["err"])
+	assert.Equal(t, Redacted, entry["db"].(map[string]interface{})["Password"])
+	// Values shorter than minSecretLength are left alone
+	assert.Equal(t, "abc", entry["short"])
+	assert.NotContains(t, buf.String(), "hunter22")
+}
+
+func TestSetLevel_AppliesToDerivedLoggers(t *testing.T) {
+	buf := capture(t)
+	derived := slog.Default().With("request_id", "r1")
+
+	require.NoError(t, SetLevel("warn"))
+	derived.Info("hidden")
+	assert.Empty(t, buf.String())
+
+	require.NoError(t, SetLevel("debug"))
+	derived.Debug("shown")
+	assert.Contains(t, buf.String(), `"request_id":"r1"`)
+
+	assert.Error(t, SetLevel("verbose"))
+	assert.Error(t, SetFormat("xml"))
+}
@@ -0,0 +1,112 @@
+// logger/scrub.go
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// Redacted replaces scrubbed values
+const Redacted = "[REDACTED]"
+
+// minSecretLength keeps very short values from being replaced everywhere
+const minSecretLength = 4
+
+// sensitiveKeys are attribute key fragments whose values are never logged
+var sensitiveKeys = []string{"password", "secret", "token", "apikey", "api_key", "authorization", "cookie", "masterkey"}
+
+// secrets holds the configured secret values, refreshed on config reload
+var secrets atomic.Pointer[[]string]
+
+// SetSecrets replaces the values scrubbed from messages and attributes
+func SetSecrets(values []string) {
+	filtered := make([]string, 0, len(values))
+	for _, value := range values {
+		if len(value) >= minSecretLength {
+			filtered = append(filtered, value)
+		}
+	}
+	secrets.Store(&filtered)
+}
+
+// scrubHandler redacts sensitive attributes and known secret values before
+// passing records on
+type scrubHandler struct {
+	next slog.Handler
+}
+
+func (h *scrubHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *scrubHandler) Handle(ctx context.Context, r slog.Record) error {
+	scrubbed := slog.NewRecord(r.Time, r.Level, scrubString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		scrubbed.AddAttrs(scrubAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, scrubbed)
+}
+
+func (h *scrubHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scrubbed := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		scrubbed[i] = scrubAttr(a)
+	}
+	return &scrubHandler{next: h.next.WithAttrs(scrubbed)}
+}
+
+func (h *scrubHandler) WithGroup(name string) slog.Handler {
+	return &scrubHandler{next: h.next.WithGroup(name)}
+}
+
+func scrubAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	if isSensitiveKey(a.Key) && a.Value.Kind() != slog.KindGroup {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, scrubString(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		scrubbed := make([]any, len(group))
+		for i, ga := range group {
+			scrubbed[i] = scrubAttr(ga)
+		}
+		return slog.Group(a.Key, scrubbed...)
+	case slog.KindAny:
+		// Errors often wrap connection strings or request URLs
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, scrubString(err.Error()))
+		}
+	}
+	return a
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range sensitiveKeys {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func scrubString(s string) string {
+	values := secrets.Load()
+	if values == nil {
+		return s
+	}
+	for _, secret := range *values {
+		if strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+	return s
+}
@@ -2,18 +2,29 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"listarr-backend/backup"
+	"listarr-backend/baseurl"
+	"listarr-backend/database"
+	"listarr-backend/docs"
+	"listarr-backend/events"
 	"listarr-backend/handlers"
-	"listarr-backend/models"
+	"listarr-backend/metrics"
+	"listarr-backend/middleware"
+	"listarr-backend/repository"
+	"listarr-backend/response"
+	"listarr-backend/server"
+	"listarr-backend/tracing"
 	"listarr-backend/utils"
-	"log"
+	"listarr-backend/web"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-
-	_ "listarr-backend/docs"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -36,57 +47,177 @@ import (
 // @schemes	http
 // @openapi	3.0.0
 func main() {
+	if len(os.Args) > 1 && runCommand(os.Args[1:]) {
+		return
+	}
+
 	if err := utils.InitConfig(); err != nil {
-		log.Fatalf("Failed to initilize conifg: %v", err)
+		fatal("Failed to initialize config", err)
 	}
 
 	appConfig := utils.GetConfig()
 
+	// Tracing settings are read once at startup
+	shutdownTracing, err := tracing.Setup(context.Background(), appConfig)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+
+	// Stop on SIGINT/SIGTERM, including while waiting for the database
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialize DB
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		appConfig.Db.Host,
-		appConfig.Db.User,
-		appConfig.Db.Password,
-		appConfig.Db.Name,
-		appConfig.Db.Port)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := database.Connect(ctx, appConfig)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal("Failed to connect to database", err)
+	}
+	if err := tracing.InstrumentDB(db, appConfig.Db.Name); err != nil {
+		fatal("Failed to instrument database", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		if err := metrics.RegisterDB(sqlDB); err != nil {
+			fatal("Failed to register database metrics", err)
+		}
 	}
-	// Auto Migrate the schema
-	db.AutoMigrate(&models.User{})
 
-	// Initialize Gin
-	r := gin.Default()
+	// Refuse to serve an outdated or failed schema
+	if err := database.Prepare(ctx, db, appConfig); err != nil {
+		fatal("Database schema is not ready", err)
+	}
 
-	// CORS Configuration
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:5173", "http://localhost:5174", "http://localhost:3000", "http://192.168.0.126:3000"} // Your frontend URL
-	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Authorization", "Content-Type"}
-	r.Use(cors.New(config))
+	// Share settings between instances through the database when configured
+	if appConfig.Settings.Backend == "database" {
+		if err := utils.UseDatabaseSettings(db); err != nil {
+			fatal("Failed to load settings from database", err)
+		}
+	}
+
+	// Initialize Gin, with route listings only when debugging
+	if appConfig.App.LogLevel != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.New()
+
+	// Forwarded headers are only honored from http.trustedProxies
+	if err := r.SetTrustedProxies(appConfig.HTTP.TrustedProxies); err != nil {
+		fatal("Invalid trusted proxies", err)
+	}
+	urlBase := baseurl.Normalize(appConfig.HTTP.URLBase)
+	forwarded, err := baseurl.Middleware(urlBase, appConfig.HTTP.TrustedProxies)
+	if err != nil {
+		fatal("Invalid trusted proxies", err)
+	}
+	r.Use(forwarded)
+	r.Use(tracing.Middleware(appConfig.Observability.ServiceName))
+	r.Use(middleware.RequestID(), middleware.AccessLog(), middleware.Recovery(), metrics.Middleware())
+
+	// CORS Configuration from auth.allowedOrigins and app.appURL
+	r.Use(middleware.CORS(utils.GetConfig))
+
+	// Probes and metrics, outside the API prefix and rate limit
+	r.GET("/healthz", handlers.Healthz)
+	r.GET("/readyz", handlers.Readyz(db))
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Everything but the probes and metrics lives under http.urlBase
+	root := r.Group(urlBase)
+	if urlBase != "" {
+		r.GET("/", func(c *gin.Context) {
+			c.Redirect(http.StatusFound, baseurl.Path(c, urlBase+"/"))
+		})
+	}
 
 	// API v1 routes
-	v1 := r.Group("/api/v1")
+	v1 := root.Group("/api/v1")
+	v1.Use(middleware.RateLimit(utils.GetConfig))
+	idempotent := middleware.NewIdempotency(db, utils.GetConfig).Middleware()
+	userRepo := repository.NewGormUsers(db)
 	{
+		v1.GET("/health", handlers.Healthz)
+		v1.GET("/ready", handlers.Readyz(db))
+
+		// Admin routes. The configuration holds auth.apiKey and every
+		// credential, so reading or changing it needs the key as well.
+		admin := middleware.RequireAdmin(utils.GetConfig)
+		system := v1.Group("/system", admin)
+		{
+			system.GET("/info", handlers.GetSystemInfo(db))
+			system.POST("/backup", handlers.CreateBackup(db))
+			system.POST("/restore", handlers.RestoreBackup(db))
+		}
+
 		// Users routes
 		users := v1.Group("/users")
 		{
-			users.POST("", handlers.CreateUser(db))
-			users.GET("", handlers.GetUsers(db))
-			users.GET("/:id", handlers.GetUser(db))
-			users.PUT("/:id", handlers.UpdateUser(db))
-			users.DELETE("/:id", handlers.DeleteUser(db))
+			users.POST("", idempotent, handlers.CreateUser(userRepo))
+			users.GET("", handlers.GetUsers(userRepo))
+			users.GET("/:id", handlers.GetUser(userRepo))
+			users.PUT("/:id", handlers.UpdateUser(userRepo))
+			users.DELETE("/:id", handlers.DeleteUser(userRepo))
 		}
-		v1.GET("/config", handlers.GetConfig)
-		v1.PUT("/config", handlers.UpdateConfig)
-		v1.POST("/config/reset", handlers.ResetConfig)
-
+		v1.GET("/config", admin, handlers.GetConfig)
+		v1.GET("/config/schema", handlers.GetConfigSchema)
+		v1.PUT("/config", admin, handlers.UpdateConfig)
+		v1.POST("/config/reset", admin, idempotent, handlers.ResetConfig)
+		v1.POST("/config/integrations/:name/test", admin, handlers.TestIntegration)
+
+		v1.GET("/events", handlers.StreamEvents(events.Default))
+		v1.GET("/events/ws", handlers.EventsWebSocket(events.Default))
 	}
 
 	// Then in your main() function, add:
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	docs.SwaggerInfo.BasePath = urlBase + "/api/v1"
+	root.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Web frontend, embedded or from frontend.dir, for paths without a route
+	var frontend *web.Frontend
+	if appConfig.Frontend.Enabled {
+		if frontend, err = web.New(appConfig.Frontend.Dir, utils.GetConfig); err != nil {
+			fatal("Failed to load the web frontend", err)
+		}
+	}
+
+	r.NoRoute(func(c *gin.Context) {
+		if frontend != nil && frontend.Serve(c) {
+			return
+		}
+		response.NotFound(c, "No route matches "+c.Request.Method+" "+c.Request.URL.Path)
+	})
 
 	// Start server
-	r.Run(":8080")
+	srv, err := server.New(r, utils.GetConfig())
+	if err != nil {
+		fatal("Failed to configure server", err)
+	}
+	srv.Go(utils.WatchSettings)
+	srv.Go(func(ctx context.Context) {
+		backup.Schedule(ctx, db, utils.GetConfig)
+	})
+	// Event streams never go idle, so end them for the shutdown to drain
+	srv.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		events.Default.Close()
+	})
+
+	if err := srv.Run(ctx); err != nil {
+		fatal("Server error", err)
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+	// Flush spans still buffered by the exporter
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Warn("Error flushing traces", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
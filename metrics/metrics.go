@@ -0,0 +1,123 @@
+// metrics/metrics.go
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric exported by the application
+const namespace = "listarr"
+
+// registry holds the application metrics plus the Go runtime and process
+// collectors. A private registry keeps metrics registered by libraries on the
+// default registry out of /metrics.
+var registry = prometheus.NewRegistry()
+
+var (
+	// ConfigReloads counts configuration reloads by result, success or failure
+	ConfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Configuration reloads by result.",
+	}, []string{"result"})
+
+	// ConfigLastReload is the Unix time of the last successful reload
+	ConfigLastReload = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_last_reload_success_timestamp_seconds",
+		Help:      "Unix time of the last successful configuration reload.",
+	})
+
+	// IntegrationRequests counts outbound requests per integration by result,
+	// success or error
+	IntegrationRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "integration_requests_total",
+		Help:      "Requests made to integrations by integration and result.",
+	}, []string{"integration", "result"})
+
+	// IntegrationDuration observes outbound request latency per integration
+	IntegrationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "integration_request_duration_seconds",
+		Help:      "Latency of requests made to integrations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"integration"})
+
+	// SyncRuns counts playlist and collection sync runs by kind and result
+	SyncRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_runs_total",
+		Help:      "Sync runs by kind and result.",
+	}, []string{"kind", "result"})
+
+	// SyncDuration observes how long sync runs take by kind
+	SyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sync_run_duration_seconds",
+		Help:      "Duration of sync runs.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, []string{"kind"})
+
+	// Downloads counts finished downloads by result
+	Downloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "downloads_total",
+		Help:      "Finished downloads by result.",
+	}, []string{"result"})
+
+	// DownloadsActive is the number of downloads in progress
+	DownloadsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "downloads_active",
+		Help:      "Downloads currently in progress.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ConfigReloads,
+		ConfigLastReload,
+		IntegrationRequests,
+		IntegrationDuration,
+		SyncRuns,
+		SyncDuration,
+		Downloads,
+		DownloadsActive,
+		httpRequests,
+		httpDuration,
+	)
+}
+
+// Register adds collectors owned by a subsystem to /metrics. Metric names
+// should start with listarr_.
+func Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MustRegister is Register that panics on error, for use from init functions
+func MustRegister(cs ...prometheus.Collector) {
+	registry.MustRegister(cs...)
+}
+
+// RegisterDB exports connection pool gauges for db
+func RegisterDB(db *sql.DB) error {
+	return Register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Handler serves the registered metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
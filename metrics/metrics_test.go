@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_LabelsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/api/v1/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/metrics", gin.WrapH(Handler()))
+
+	for _, path := range []string{"/api/v1/users/1", "/api/v1/users/2", "/no/such/path"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/api/v1/users/:id", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", unmatchedRoute, "404")))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `listarr_http_request_duration_seconds_count{method="GET",route="/api/v1/users/:id"} 2`)
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
+
+func TestInstrumentTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: InstrumentTransport("plex", http.DefaultTransport)}
+	for _, path := range []string{"/", "/fail"} {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(IntegrationRequests.WithLabelValues("plex", "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(IntegrationRequests.WithLabelValues("plex", "error")))
+	assert.Equal(t, 1, testutil.CollectAndCount(IntegrationDuration, "listarr_integration_request_duration_seconds"))
+}
+
+func TestRegister(t *testing.T) {
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "listarr_test_total", Help: "Test counter."})
+	require.NoError(t, Register(counter))
+	assert.Error(t, Register(counter), "registering twice should fail")
+}
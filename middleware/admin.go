@@ -0,0 +1,38 @@
+// middleware/admin.go
+package middleware
+
+import (
+	"crypto/subtle"
+	"listarr-backend/models"
+	"listarr-backend/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin returns middleware allowing only requests carrying auth.apiKey
+// in the X-Api-Key header. Admin endpoints answer 403 while no key is set.
+func RequireAdmin(config func() *models.Configuration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config()
+		if cfg == nil || cfg.Auth.APIKey == "" {
+			response.Problem(c, http.StatusForbidden, models.CodeForbidden, "Admin endpoints are disabled, set auth.apiKey to enable them")
+			return
+		}
+
+		if !IsAdmin(cfg, c.GetHeader(APIKeyHeader)) {
+			response.Problem(c, http.StatusUnauthorized, models.CodeUnauthorized, "Missing or invalid API key")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether apiKey is the configured admin key
+func IsAdmin(cfg *models.Configuration, apiKey string) bool {
+	if cfg == nil || cfg.Auth.APIKey == "" || apiKey == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.Auth.APIKey)) == 1
+}
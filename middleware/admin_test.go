@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"listarr-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &models.Configuration{}
+
+	r := gin.New()
+	r.GET("/system/info", RequireAdmin(func() *models.Configuration { return cfg }), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(apiKey string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/system/info", nil)
+		if apiKey != "" {
+			req.Header.Set(APIKeyHeader, apiKey)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Disabled until a key is configured
+	assert.Equal(t, http.StatusForbidden, request(""))
+	assert.Equal(t, http.StatusForbidden, request("anything"))
+
+	cfg.Auth.APIKey = "admin-key"
+	assert.Equal(t, http.StatusUnauthorized, request(""))
+	assert.Equal(t, http.StatusUnauthorized, request("wrong-key"))
+	assert.Equal(t, http.StatusOK, request("admin-key"))
+}
@@ -0,0 +1,75 @@
+// middleware/cors.go
+package middleware
+
+import (
+	"listarr-backend/models"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS returns middleware allowing the origins in auth.allowedOrigins plus the
+// origin of app.appURL. The configuration is read on every request so origin
+// changes apply on reload. Entries may use a wildcard for subdomains, as in
+// https://*.example.com, and credentials are allowed.
+func CORS(config func() *models.Configuration) gin.HandlerFunc {
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOriginFunc = func(origin string) bool {
+		cfg := config()
+		return cfg != nil && OriginAllowed(cfg, origin)
+	}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Authorization", "Content-Type", APIKeyHeader, RequestIDHeader, "If-Match", "If-None-Match", IdempotencyKeyHeader, "Last-Event-ID"}
+	corsConfig.ExposeHeaders = []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", RequestIDHeader, "Link", "X-Total-Count", "ETag", IdempotentReplayedHeader}
+	corsConfig.AllowCredentials = true
+	corsConfig.MaxAge = 12 * time.Hour
+	return cors.New(corsConfig)
+}
+
+// OriginAllowed reports whether origin matches app.appURL or an entry of
+// auth.allowedOrigins
+func OriginAllowed(cfg *models.Configuration, origin string) bool {
+	origin = strings.ToLower(origin)
+
+	if appOrigin := originOf(cfg.App.AppURL); appOrigin != "" && appOrigin == origin {
+		return true
+	}
+
+	for _, allowed := range cfg.Auth.AllowedOrigins {
+		if matchOrigin(strings.ToLower(strings.TrimSuffix(allowed, "/")), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOrigin compares origin with pattern, where pattern may be * or contain
+// a leading *. in its host to match any subdomain
+func matchOrigin(pattern, origin string) bool {
+	if pattern == "*" || pattern == origin {
+		return true
+	}
+
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok {
+		return false
+	}
+	prefix := scheme + "://"
+	if !strings.HasPrefix(origin, prefix) {
+		return false
+	}
+	// The apex domain itself is not matched by the wildcard
+	return strings.HasSuffix(strings.TrimPrefix(origin, prefix), "."+host)
+}
+
+// originOf returns the scheme://host[:port] part of rawURL
+func originOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
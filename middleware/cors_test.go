@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"listarr-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	cfg := &models.Configuration{}
+	cfg.App.AppURL = "https://listarr.example.com/app"
+	cfg.Auth.AllowedOrigins = []string{"http://localhost:5173/", "https://*.media.example.com"}
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://listarr.example.com", true},
+		{"http://localhost:5173", true},
+		{"https://tv.media.example.com", true},
+		{"https://a.b.media.example.com", true},
+		{"https://media.example.com", false},
+		{"http://tv.media.example.com", false},
+		{"https://evilmedia.example.com", false},
+		{"http://localhost:3000", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, OriginAllowed(cfg, tt.origin))
+		})
+	}
+}
+
+func TestCORS_FollowsConfigChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &models.Configuration{}
+	cfg.Auth.AllowedOrigins = []string{"http://localhost:3000"}
+
+	r := gin.New()
+	r.Use(CORS(func() *models.Configuration { return cfg }))
+	r.GET("/api/v1/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(origin string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("OPTIONS", "/api/v1/users", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("http://localhost:3000")
+	assert.Equal(t, "http://localhost:3000", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, http.StatusForbidden, request("https://new-host.example.com").Code)
+
+	cfg.Auth.AllowedOrigins = append(cfg.Auth.AllowedOrigins, "https://new-host.example.com")
+	assert.Equal(t, "https://new-host.example.com", request("https://new-host.example.com").Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,249 @@
+// middleware/idempotency.go
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"listarr-backend/logger"
+	"listarr-backend/models"
+	"listarr-backend/response"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// IdempotencyKeyHeader lets clients retry a POST without repeating its effect
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize bounds the request body read to fingerprint a
+	// request
+	maxIdempotentBodySize = 1 << 20
+)
+
+// Idempotency replays the stored response of the first request carrying an
+// Idempotency-Key to retries with the same key. Keys are scoped per client
+// like rate limits, and responses are kept in the database for
+// http.idempotencyWindow hours so every instance sees them.
+type Idempotency struct {
+	db     *gorm.DB
+	config func() *models.Configuration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewIdempotency creates the middleware state, storing responses in db
+func NewIdempotency(db *gorm.DB, config func() *models.Configuration) *Idempotency {
+	return &Idempotency{db: db, config: config, now: time.Now}
+}
+
+// Middleware returns the gin handler. Requests without the header pass
+// through unchanged.
+func (i *Idempotency) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		cfg := i.config()
+		if key == "" || cfg == nil || cfg.HTTP.IdempotencyWindow <= 0 {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			response.Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.Problem(c, http.StatusRequestEntityTooLarge, models.CodePayloadTooLarge,
+				fmt.Sprintf("Request body must be at most %d bytes", tooLarge.Limit))
+			return
+		}
+		if err != nil {
+			response.Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, "Could not read the request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Storing the outcome must not fail because the client went away
+		ctx := context.WithoutCancel(c.Request.Context())
+		now := i.now()
+		i.sweep(ctx, now)
+
+		record := models.IdempotencyKey{
+			Client:      clientKey(c, cfg),
+			Key:         key,
+			Fingerprint: fingerprint(c.Request, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(time.Duration(cfg.HTTP.IdempotencyWindow) * time.Hour),
+		}
+
+		existing, err := i.claim(ctx, record)
+		if err != nil {
+			response.Internal(c, err)
+			return
+		}
+		if existing != nil {
+			replay(c, existing, record.Fingerprint)
+			return
+		}
+
+		// Release the key if the handler fails or panics so the request can
+		// be retried
+		completed := false
+		defer func() {
+			if !completed {
+				i.release(ctx, record)
+			}
+		}()
+
+		recorder := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		err = i.db.WithContext(ctx).Model(&record).Select("Status", "ContentType", "ETag", "Location", "Body").Updates(models.IdempotencyKey{
+			Status:      recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			ETag:        recorder.Header().Get("ETag"),
+			Location:    recorder.Header().Get("Location"),
+			Body:        recorder.body.Bytes(),
+		}).Error
+		if err != nil {
+			logger.FromContext(ctx).Error("Failed to store idempotent response", "error", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// claim inserts record, or returns the live record already stored under the
+// same client and key. Expired records are replaced.
+func (i *Idempotency) claim(ctx context.Context, record models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	where := models.IdempotencyKey{Client: record.Client, Key: record.Key}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		result := i.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing models.IdempotencyKey
+		err := i.db.WithContext(ctx).Where(&where).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Released or swept since the insert, try again
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if existing.ExpiresAt.After(record.CreatedAt) {
+			return &existing, nil
+		}
+
+		if err := i.db.WithContext(ctx).Where(&where).Where("expires_at <= ?", record.CreatedAt).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("idempotency key changed while it was claimed")
+}
+
+// release drops an unfinished record
+func (i *Idempotency) release(ctx context.Context, record models.IdempotencyKey) {
+	err := i.db.WithContext(ctx).
+		Where(&models.IdempotencyKey{Client: record.Client, Key: record.Key}).
+		Where("status = 0").
+		Delete(&models.IdempotencyKey{}).Error
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to release idempotency key", "error", err)
+	}
+}
+
+// sweep deletes expired records at most once a minute
+func (i *Idempotency) sweep(ctx context.Context, now time.Time) {
+	i.mu.Lock()
+	if now.Sub(i.lastSweep) < time.Minute {
+		i.mu.Unlock()
+		return
+	}
+	i.lastSweep = now
+	i.mu.Unlock()
+
+	if err := i.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		logger.FromContext(ctx).Warn("Failed to delete expired idempotency keys", "error", err)
+	}
+}
+
+// replay answers a retry from the stored record
+func replay(c *gin.Context, existing *models.IdempotencyKey, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		response.Problem(c, http.StatusUnprocessableEntity, models.CodeIdempotencyKeyReused,
+			"This Idempotency-Key was already used for a different request")
+		return
+	}
+	if existing.Status == 0 {
+		c.Header("Retry-After", "1")
+		response.Problem(c, http.StatusConflict, models.CodeConflict,
+			"A request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	for header, value := range map[string]string{
+		"Content-Type": existing.ContentType,
+		"ETag":         existing.ETag,
+		"Location":     existing.Location,
+	} {
+		if value != "" {
+			c.Header(header, value)
+		}
+	}
+	c.Status(existing.Status)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Write(existing.Body)
+	c.Abort()
+}
+
+// fingerprint identifies a request by method, path with query and body
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
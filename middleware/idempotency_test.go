@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"listarr-backend/database/dbtest"
+	"listarr-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type idempotencyTest struct {
+	db     *gorm.DB
+	clock  time.Time
+	calls  int
+	status int
+	router *gin.Engine
+}
+
+func newIdempotencyTest(t *testing.T) *idempotencyTest {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.OpenMigrated(t)
+
+	it := &idempotencyTest{db: db, clock: time.Unix(1700000000, 0), status: http.StatusCreated}
+	cfg := &models.Configuration{}
+	cfg.HTTP.IdempotencyWindow = 24
+
+	idempotency := NewIdempotency(db, func() *models.Configuration { return cfg })
+	idempotency.now = func() time.Time { return it.clock }
+
+	it.router = gin.New()
+	it.router.POST("/users", idempotency.Middleware(), func(c *gin.Context) {
+		it.calls++
+		c.Header("Location", "/users/1")
+		c.JSON(it.status, gin.H{"call": it.calls})
+	})
+	return it
+}
+
+func (it *idempotencyTest) post(key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBufferString(body))
+	req.RemoteAddr = "10.0.0.1:1234"
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	it.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysFirstResponse(t *testing.T) {
+	it := newIdempotencyTest(t)
+
+	first := it.post("abc", `{"name":"Ann"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	retry := it.post("abc", `{"name":"Ann"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, "/users/1", retry.Header().Get("Location"))
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, 1, it.calls)
+
+	// Requests without a key or with another key are handled normally
+	assert.JSONEq(t, `{"call":2}`, it.post("", `{"name":"Ann"}`).Body.String())
+	assert.JSONEq(t, `{"call":3}`, it.post("def", `{"name":"Ann"}`).Body.String())
+
+	// Keys expire after the window
+	it.clock = it.clock.Add(25 * time.Hour)
+	assert.JSONEq(t, `{"call":4}`, it.post("abc", `{"name":"Ann"}`).Body.String())
+}
+
+func TestIdempotency_RejectsLargeBodies(t *testing.T) {
+	it := newIdempotencyTest(t)
+
+	w := it.post("abc", `{"name":"`+strings.Repeat("a", maxIdempotentBodySize)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, models.CodePayloadTooLarge, problem.Code)
+	assert.Equal(t, 0, it.calls)
+
+	// The key was never claimed
+	assert.Equal(t, http.StatusCreated, it.post("abc", `{"name":"Ann"}`).Code)
+}
+
+func TestIdempotency_RejectsReuseWithDifferentRequest(t *testing.T) {
+	it := newIdempotencyTest(t)
+
+	it.post("abc", `{"name":"Ann"}`)
+	w := it.post("abc", `{"name":"Bob"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, models.CodeIdempotencyKeyReused, problem.Code)
+	assert.Equal(t, 1, it.calls)
+}
+
+func TestIdempotency_InFlightAndFailedRequests(t *testing.T) {
+	it := newIdempotencyTest(t)
+
+	// A request still being handled elsewhere
+	require.NoError(t, it.db.Create(&models.IdempotencyKey{
+		Client:      "ip:10.0.0.1",
+		Key:         "busy",
+		Fingerprint: fingerprint(httptest.NewRequest("POST", "/users", nil), []byte(`{}`)),
+		ExpiresAt:   it.clock.Add(time.Hour),
+	}).Error)
+	w := it.post("busy", `{}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, 0, it.calls)
+
+	// Server errors release the key so the retry runs again
+	it.status = http.StatusInternalServerError
+	assert.Equal(t, http.StatusInternalServerError, it.post("abc", `{}`).Code)
+	it.status = http.StatusCreated
+	w = it.post("abc", `{}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 2, it.calls)
+}
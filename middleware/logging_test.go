@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"listarr-backend/logger"
+	"listarr-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	require.NoError(t, logger.SetFormat(logger.FormatJSON))
+	logger.SetOutput(&buf)
+	defer logger.SetOutput(nil)
+
+	r := gin.New()
+	r.Use(RequestID(), AccessLog(), Recovery())
+	r.GET("/api/v1/users/:id", func(c *gin.Context) {
+		logger.FromContext(c.Request.Context()).Info("loading user")
+		c.Status(http.StatusOK)
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	// A client supplied ID is kept and shows up on every log line
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/users/7", nil)
+	req.Header.Set(RequestIDHeader, "trace-42")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "trace-42", w.Header().Get(RequestIDHeader))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var access map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &access))
+	assert.Equal(t, "trace-42", access["request_id"])
+	assert.Equal(t, "/api/v1/users/:id", access["route"])
+	assert.Equal(t, float64(200), access["status"])
+	assert.Contains(t, lines[0], `"request_id":"trace-42"`)
+
+	// Malformed IDs are replaced and panics become logged 500s
+	buf.Reset()
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/panic", nil)
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Len(t, w.Header().Get(RequestIDHeader), 32)
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, models.CodeInternal, problem.Code)
+	assert.Equal(t, w.Header().Get(RequestIDHeader), problem.RequestID)
+	assert.Contains(t, buf.String(), "Panic while handling request")
+	assert.Contains(t, buf.String(), `"level":"ERROR"`)
+}
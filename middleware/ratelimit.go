@@ -0,0 +1,165 @@
+// middleware/ratelimit.go
+package middleware
+
+import (
+	"fmt"
+	"listarr-backend/baseurl"
+	"listarr-backend/models"
+	"listarr-backend/response"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// UserIDKey is the gin context key authentication middleware sets to the
+	// ID of the signed in user
+	UserIDKey = "userID"
+	// APIKeyHeader carries API keys used by scripts and external tools
+	APIKeyHeader = "X-Api-Key"
+
+	// authPathPrefix selects the stricter budget for sign in and token endpoints
+	authPathPrefix = "/api/v1/auth"
+	// bucketIdleTTL is how long an untouched bucket is kept before it is dropped
+	bucketIdleTTL = 10 * time.Minute
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a token bucket limiter keyed per admin key, user or client IP.
+// Limits are read from the configuration on every request so changes apply
+// without a restart.
+type RateLimiter struct {
+	config func() *models.Configuration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter creates a limiter reading its limits from config
+func NewRateLimiter(config func() *models.Configuration) *RateLimiter {
+	return &RateLimiter{
+		config:  config,
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// RateLimit returns middleware enforcing http.requestsPerMin, and
+// http.authRequestsPerMin on authentication endpoints
+func RateLimit(config func() *models.Configuration) gin.HandlerFunc {
+	return NewRateLimiter(config).Middleware()
+}
+
+// Middleware returns the gin handler for the limiter
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := l.config()
+		if cfg == nil || !cfg.HTTP.RateLimitEnabled || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		budget, limit := "api", cfg.HTTP.RequestsPerMin
+		if path, _ := baseurl.Strip(c); strings.HasPrefix(path, authPathPrefix) {
+			budget, limit = "auth", cfg.HTTP.AuthRequestsPerMin
+		}
+		// A limit of zero disables the budget
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		allowed, remaining, retryAfter, reset := l.take(budget+"|"+clientKey(c, cfg), limit)
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(seconds(reset)))
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(seconds(retryAfter)))
+			response.Problem(c, http.StatusTooManyRequests, models.CodeRateLimited,
+				fmt.Sprintf("Too many requests, retry in %d seconds", seconds(retryAfter)))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// take removes a token from the bucket for key. It reports whether the request
+// may proceed, the tokens left, the wait until the next token and the wait
+// until the bucket is full again.
+func (l *RateLimiter) take(key string, perMinute int) (bool, int, time.Duration, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	capacity := float64(perMinute)
+	rate := capacity / time.Minute.Seconds()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+
+	// Refill for the time elapsed, clamped in case the limit was lowered
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	retryAfter := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	reset := time.Duration((capacity - b.tokens) / rate * float64(time.Second))
+	return allowed, int(b.tokens), retryAfter, reset
+}
+
+// sweep drops buckets that have not been used recently
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > bucketIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientKey identifies the caller by admin key, then signed in user, then IP.
+// Only a valid key gets its own bucket, so sending made up keys does not
+// escape the limit of the client IP.
+func clientKey(c *gin.Context, cfg *models.Configuration) string {
+	if IsAdmin(cfg, c.GetHeader(APIKeyHeader)) {
+		return "key:admin"
+	}
+	if userID, ok := c.Get(UserIDKey); ok {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
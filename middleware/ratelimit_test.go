@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"encoding/json"
+	"listarr-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type limiterTest struct {
+	cfg     *models.Configuration
+	clock   time.Time
+	limiter *RateLimiter
+	router  *gin.Engine
+}
+
+func newLimiterTest(perMin, authPerMin int) *limiterTest {
+	gin.SetMode(gin.TestMode)
+	lt := &limiterTest{cfg: &models.Configuration{}, clock: time.Unix(1700000000, 0)}
+	lt.cfg.HTTP.RateLimitEnabled = true
+	lt.cfg.HTTP.RequestsPerMin = perMin
+	lt.cfg.HTTP.AuthRequestsPerMin = authPerMin
+	lt.cfg.Auth.APIKey = "admin-key"
+
+	lt.limiter = NewRateLimiter(func() *models.Configuration { return lt.cfg })
+	lt.limiter.now = func() time.Time { return lt.clock }
+
+	lt.router = gin.New()
+	lt.router.Use(lt.limiter.Middleware())
+	lt.router.GET("/api/v1/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	lt.router.POST("/api/v1/auth/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return lt
+}
+
+func (lt *limiterTest) do(method, path, ip, apiKey string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	req.RemoteAddr = ip + ":1234"
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+	lt.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_BlocksAfterBudget(t *testing.T) {
+	lt := newLimiterTest(3, 1)
+
+	for i := 0; i < 3; i++ {
+		w := lt.do("GET", "/api/v1/users", "10.0.0.1", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+	}
+
+	w := lt.do("GET", "/api/v1/users", "10.0.0.1", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "20", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	var body models.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, models.CodeRateLimited, body.Code)
+	assert.Equal(t, http.StatusTooManyRequests, body.Status)
+	assert.Contains(t, body.Detail, "Too many requests")
+
+	// Other clients and the admin key have their own buckets
+	assert.Equal(t, http.StatusOK, lt.do("GET", "/api/v1/users", "10.0.0.2", "").Code)
+	assert.Equal(t, http.StatusOK, lt.do("GET", "/api/v1/users", "10.0.0.1", "admin-key").Code)
+
+	// Unknown keys share the bucket of the client IP
+	assert.Equal(t, http.StatusTooManyRequests, lt.do("GET", "/api/v1/users", "10.0.0.1", "random-key").Code)
+
+	// One token is back after a third of a minute
+	lt.clock = lt.clock.Add(20 * time.Second)
+	assert.Equal(t, http.StatusOK, lt.do("GET", "/api/v1/users", "10.0.0.1", "").Code)
+}
+
+func TestRateLimit_SeparateAuthBudget(t *testing.T) {
+	lt := newLimiterTest(100, 1)
+
+	assert.Equal(t, http.StatusOK, lt.do("POST", "/api/v1/auth/login", "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, lt.do("POST", "/api/v1/auth/login", "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusOK, lt.do("GET", "/api/v1/users", "10.0.0.1", "").Code)
+}
+
+func TestRateLimit_FollowsConfigChanges(t *testing.T) {
+	lt := newLimiterTest(1, 1)
+
+	assert.Equal(t, http.StatusOK, lt.do("GET", "/api/v1/users", "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, lt.do("GET", "/api/v1/users", "10.0.0.1", "").Code)
+
+	lt.cfg.HTTP.RateLimitEnabled = false
+	assert.Equal(t, http.StatusOK, lt.do("GET", "/api/v1/users", "10.0.0.1", "").Code)
+
+	// A higher limit refills faster from the next request on
+	lt.cfg.HTTP.RateLimitEnabled = true
+	lt.cfg.HTTP.RequestsPerMin = 60
+	lt.clock = lt.clock.Add(time.Second)
+	assert.Equal(t, http.StatusOK, lt.do("GET", "/api/v1/users", "10.0.0.1", "").Code)
+	assert.Equal(t, "60", lt.do("GET", "/api/v1/users", "10.0.0.2", "").Header().Get("X-RateLimit-Limit"))
+}
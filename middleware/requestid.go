@@ -0,0 +1,69 @@
+// middleware/requestid.go
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"listarr-backend/logger"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// RequestIDHeader carries the request ID from clients and proxies and back
+	// in the response
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey is the gin context key holding the request ID
+	RequestIDKey = "requestID"
+
+	// maxRequestIDLength bounds IDs accepted from clients
+	maxRequestIDLength = 64
+)
+
+// RequestID assigns every request an ID, reusing a well-formed X-Request-ID
+// from the client, and stores a logger carrying it and the trace ID in the
+// request context
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		requestLogger := slog.Default().With("request_id", id)
+		// Link log lines to the trace started by the tracing middleware
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			requestLogger = requestLogger.With("trace_id", span.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), requestLogger))
+
+		c.Next()
+	}
+}
+
+// validRequestID accepts IDs made of letters, digits, dots, dashes and
+// underscores so they are safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
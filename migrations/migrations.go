@@ -0,0 +1,421 @@
+// migrations/migrations.go
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var embedded embed.FS
+
+// Postgres and SQLite hold the migrations for each database engine. Both
+// must define the same versions.
+var (
+	Postgres = mustSub(embedded, "postgres")
+	SQLite   = mustSub(embedded, "sqlite")
+)
+
+// lockID is the Postgres advisory lock held while migrating, so replicas
+// starting together do not run the same migration twice
+const lockID = 7265237
+
+// fileName matches migration files such as 0002_add_lists.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Record is the row of schema_migrations tracking a migration. A dirty record
+// is a migration that failed and must be looked at before the server starts.
+type Record struct {
+	Version   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255;not null"`
+	Dirty     bool   `gorm:"not null"`
+	Error     string
+	AppliedAt time.Time
+}
+
+// TableName keeps the conventional name used by migration tools
+func (Record) TableName() string {
+	return "schema_migrations"
+}
+
+// FailedError reports a migration left dirty by an earlier run
+type FailedError struct {
+	Record Record
+}
+
+func (e *FailedError) Error() string {
+	return fmt.Sprintf("migration %d (%s) failed at %s: %s; fix the cause, then run `migrate up -retry`",
+		e.Record.Version, e.Record.Name, e.Record.AppliedAt.Format(time.RFC3339), e.Record.Error)
+}
+
+// Status describes the schema of a database
+type Status struct {
+	// Current is the version of the last applied migration, 0 for none
+	Current uint
+	// Latest is the version of the last known migration
+	Latest  uint
+	Applied []Record
+	Pending []Migration
+	Failed  *Record
+}
+
+// Migrator applies migrations to a database and records them in
+// schema_migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New loads the migrations in fsys for db
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load reads migrations named <version>_<name>.up.sql and .down.sql from
+// fsys, ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[m.Version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, match[2])
+		}
+
+		data, err := fs.ReadFile(fsys, path.Clean(entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", entry.Name(), err)
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Status reads the applied migrations from the database
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	var status Status
+	err := m.session(ctx, func(db *gorm.DB) error {
+		var err error
+		status, err = m.status(db)
+		return err
+	})
+	return status, err
+}
+
+// Check returns an error unless every known migration has been applied
+// successfully, so the server never runs against an outdated or half
+// migrated schema
+func (m *Migrator) Check(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Failed != nil {
+		return &FailedError{Record: *status.Failed}
+	}
+	if status.Current > status.Latest {
+		return fmt.Errorf("database schema version %d is newer than the latest migration %d of this build", status.Current, status.Latest)
+	}
+	if len(status.Pending) > 0 {
+		return fmt.Errorf("database schema is at version %d but %d migrations up to version %d are pending; run `migrate up` or enable db.migrateOnStart",
+			status.Current, len(status.Pending), status.Latest)
+	}
+	return nil
+}
+
+// Up applies the pending migrations in order and returns those applied. It
+// stops at the first failure, which is recorded in a dirty record so the
+// server refuses to start until someone has looked at it.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.session(ctx, func(db *gorm.DB) error {
+		var err error
+		applied, err = m.upLatest(db)
+		return err
+	})
+	return applied, err
+}
+
+// Down rolls back the last steps applied migrations and returns those
+// rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var rolledBack []Migration
+	err := m.session(ctx, func(db *gorm.DB) error {
+		status, err := m.ready(db)
+		if err != nil {
+			return err
+		}
+		rolledBack, err = m.down(db, status, steps)
+		return err
+	})
+	return rolledBack, err
+}
+
+// Tx migrates the database inside a transaction opened by
+// Migrator.Transaction
+type Tx struct {
+	// DB is the transaction, for statements committed or rolled back along
+	// with the migrations
+	DB       *gorm.DB
+	migrator *Migrator
+}
+
+// Transaction runs fn in one transaction holding the migration lock. The
+// migrations run through tx are committed with the other statements of fn,
+// or all rolled back when fn returns an error, failures included.
+func (m *Migrator) Transaction(ctx context.Context, fn func(tx *Tx) error) error {
+	return m.session(ctx, func(db *gorm.DB) error {
+		return db.Transaction(func(db *gorm.DB) error {
+			return fn(&Tx{DB: db, migrator: m})
+		})
+	})
+}
+
+// Up applies the pending migrations in order and returns those applied
+func (tx *Tx) Up() ([]Migration, error) {
+	return tx.migrator.upLatest(tx.DB)
+}
+
+// MigrateTo applies or rolls back migrations until version is the last one
+// applied, 0 rolling back every migration, and returns those applied or
+// rolled back
+func (tx *Tx) MigrateTo(version uint) ([]Migration, error) {
+	m := tx.migrator
+	if _, ok := m.find(version); !ok && version != 0 {
+		return nil, fmt.Errorf("migration %d is not part of this build", version)
+	}
+
+	status, err := m.ready(tx.DB)
+	if err != nil {
+		return nil, err
+	}
+	if version >= status.Current {
+		return m.up(tx.DB, status, version)
+	}
+
+	steps := 0
+	for _, record := range status.Applied {
+		if record.Version > version {
+			steps++
+		}
+	}
+	return m.down(tx.DB, status, steps)
+}
+
+// ready reads the status of db, refusing to continue past a failed migration
+func (m *Migrator) ready(db *gorm.DB) (Status, error) {
+	status, err := m.status(db)
+	if err != nil {
+		return status, err
+	}
+	if status.Failed != nil {
+		return status, &FailedError{Record: *status.Failed}
+	}
+	return status, nil
+}
+
+// upLatest applies every pending migration
+func (m *Migrator) upLatest(db *gorm.DB) ([]Migration, error) {
+	status, err := m.ready(db)
+	if err != nil {
+		return nil, err
+	}
+	return m.up(db, status, status.Latest)
+}
+
+// up applies the pending migrations up to version
+func (m *Migrator) up(db *gorm.DB, status Status, version uint) ([]Migration, error) {
+	var applied []Migration
+	for _, migration := range status.Pending {
+		if migration.Version > version {
+			break
+		}
+
+		slog.Info("Applying migration", "version", migration.Version, "name", migration.Name)
+		err := apply(db, migration.Up, func(tx *gorm.DB) error {
+			return save(tx, Record{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC()})
+		})
+		if err != nil {
+			failed := Record{Version: migration.Version, Name: migration.Name, Dirty: true, Error: err.Error(), AppliedAt: time.Now().UTC()}
+			if saveErr := save(db, failed); saveErr != nil {
+				err = errors.Join(err, fmt.Errorf("error recording the failure: %w", saveErr))
+			}
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// down rolls back the last steps applied migrations
+func (m *Migrator) down(db *gorm.DB, status Status, steps int) ([]Migration, error) {
+	var rolledBack []Migration
+	for i := len(status.Applied) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		record := status.Applied[i]
+		migration, ok := m.find(record.Version)
+		if !ok {
+			return rolledBack, fmt.Errorf("migration %d (%s) is not part of this build and cannot be rolled back", record.Version, record.Name)
+		}
+		if migration.Down == "" {
+			return rolledBack, fmt.Errorf("migration %d (%s) has no down file", migration.Version, migration.Name)
+		}
+
+		slog.Info("Rolling back migration", "version", migration.Version, "name", migration.Name)
+		// A failed rollback leaves the migration applied, so nothing is
+		// recorded
+		if err := apply(db, migration.Down, func(tx *gorm.DB) error {
+			return tx.Delete(&Record{}, migration.Version).Error
+		}); err != nil {
+			return rolledBack, fmt.Errorf("rolling back migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+// ClearFailed forgets a failed migration so Up retries it. Each migration
+// runs in a transaction, so a failure normally leaves nothing to clean up.
+func (m *Migrator) ClearFailed(ctx context.Context) error {
+	return m.session(ctx, func(db *gorm.DB) error {
+		return db.Where("dirty = ?", true).Delete(&Record{}).Error
+	})
+}
+
+// session runs fn on a single connection holding the migration lock, after
+// creating schema_migrations if needed
+func (m *Migrator) session(ctx context.Context, fn func(db *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(db *gorm.DB) error {
+		if db.Dialector.Name() == "postgres" {
+			if err := db.Exec("SELECT pg_advisory_lock(?)", lockID).Error; err != nil {
+				return fmt.Errorf("error taking the migration lock: %w", err)
+			}
+			defer db.Exec("SELECT pg_advisory_unlock(?)", lockID)
+		}
+
+		if err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			dirty BOOLEAN NOT NULL,
+			error TEXT,
+			applied_at TIMESTAMP
+		)`).Error; err != nil {
+			return fmt.Errorf("error creating schema_migrations: %w", err)
+		}
+		return fn(db)
+	})
+}
+
+func (m *Migrator) status(db *gorm.DB) (Status, error) {
+	var records []Record
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return Status{}, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+
+	var status Status
+	if len(m.migrations) > 0 {
+		status.Latest = m.migrations[len(m.migrations)-1].Version
+	}
+
+	done := map[uint]bool{}
+	for _, record := range records {
+		if record.Dirty {
+			status.Failed = &record
+			continue
+		}
+		status.Applied = append(status.Applied, record)
+		status.Current = record.Version
+		done[record.Version] = true
+	}
+	for _, migration := range m.migrations {
+		if !done[migration.Version] {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// apply runs sql and record in one transaction, so a failing migration
+// leaves the schema unchanged
+func apply(db *gorm.DB, sql string, record func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+		return record(tx)
+	})
+}
+
+// save inserts or replaces the record of a migration
+func save(db *gorm.DB, record Record) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&record).Error
+}
+
+func (m *Migrator) find(version uint) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,199 @@
+package migrations_test
+
+import (
+	"context"
+	"errors"
+	"listarr-backend/database/dbtest"
+	"listarr-backend/migrations"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_create_lists.up.sql":     {Data: []byte("CREATE TABLE lists (id INTEGER PRIMARY KEY, name TEXT NOT NULL);")},
+	"0001_create_lists.down.sql":   {Data: []byte("DROP TABLE lists;")},
+	"0002_add_list_owner.up.sql":   {Data: []byte("ALTER TABLE lists ADD COLUMN owner TEXT;\nCREATE INDEX idx_lists_owner ON lists (owner);")},
+	"0002_add_list_owner.down.sql": {Data: []byte("DROP INDEX idx_lists_owner;\nALTER TABLE lists DROP COLUMN owner;")},
+	"README.md":                    {Data: []byte("not a migration")},
+}
+
+func TestLoad(t *testing.T) {
+	loaded, err := migrations.Load(testMigrations)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, uint(1), loaded[0].Version)
+	assert.Equal(t, "create_lists", loaded[0].Name)
+	assert.Equal(t, "add_list_owner", loaded[1].Name)
+	assert.Contains(t, loaded[1].Down, "DROP COLUMN owner")
+
+	_, err = migrations.Load(fstest.MapFS{"0003_orphan.down.sql": {Data: []byte("SELECT 1;")}})
+	assert.ErrorContains(t, err, "has no up file")
+
+	_, err = migrations.Load(fstest.MapFS{
+		"0003_one.up.sql": {Data: []byte("SELECT 1;")},
+		"0003_two.up.sql": {Data: []byte("SELECT 1;")},
+	})
+	assert.ErrorContains(t, err, "named both")
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	postgres, err := migrations.Load(migrations.Postgres)
+	require.NoError(t, err)
+	sqlite, err := migrations.Load(migrations.SQLite)
+	require.NoError(t, err)
+	require.NotEmpty(t, postgres)
+	require.Equal(t, len(postgres), len(sqlite), "every migration needs a version for each database")
+
+	for i, m := range postgres {
+		assert.Equal(t, uint(i+1), m.Version, "versions must have no gaps")
+		assert.Equal(t, m.Name, sqlite[i].Name)
+		assert.NotEmpty(t, m.Down, "migration %d has no down file", m.Version)
+		assert.NotEmpty(t, sqlite[i].Down, "migration %d has no down file", m.Version)
+	}
+
+	// The migrations.SQLite schema can be applied and rolled back
+	ctx := context.Background()
+	m, err := migrations.New(dbtest.Open(t), migrations.SQLite)
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+	_, err = m.Down(ctx, len(sqlite))
+	require.NoError(t, err)
+}
+
+func TestUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+	m, err := migrations.New(db, testMigrations)
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, m.Check(ctx), "2 migrations up to version 2 are pending")
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	require.NoError(t, m.Check(ctx))
+	require.NoError(t, db.Exec("INSERT INTO lists (name, owner) VALUES ('Favourites', 'alice')").Error)
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	rolledBack, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rolledBack, 1)
+	assert.Equal(t, uint(2), rolledBack[0].Version)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), status.Current)
+	assert.Equal(t, uint(2), status.Latest)
+	require.Len(t, status.Pending, 1)
+	assert.Equal(t, "add_list_owner", status.Pending[0].Name)
+	assert.False(t, db.Migrator().HasColumn("lists", "owner"))
+
+	rolledBack, err = m.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Len(t, rolledBack, 1)
+	assert.False(t, db.Migrator().HasTable("lists"))
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+	m, err := migrations.New(db, testMigrations)
+	require.NoError(t, err)
+
+	err = m.Transaction(ctx, func(tx *migrations.Tx) error {
+		migrated, err := tx.MigrateTo(1)
+		require.NoError(t, err)
+		require.Len(t, migrated, 1)
+		assert.True(t, tx.DB.Migrator().HasTable("lists"))
+		assert.False(t, tx.DB.Migrator().HasColumn("lists", "owner"))
+
+		migrated, err = tx.MigrateTo(2)
+		require.NoError(t, err)
+		require.Len(t, migrated, 1)
+		assert.Equal(t, uint(2), migrated[0].Version)
+
+		migrated, err = tx.MigrateTo(0)
+		require.NoError(t, err)
+		assert.Len(t, migrated, 2)
+		assert.False(t, tx.DB.Migrator().HasTable("lists"))
+
+		_, err = tx.MigrateTo(3)
+		assert.ErrorContains(t, err, "not part of this build")
+
+		applied, err := tx.Up()
+		require.NoError(t, err)
+		assert.Len(t, applied, 2)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.Check(ctx))
+	require.NoError(t, db.Exec("INSERT INTO lists (name, owner) VALUES ('Favourites', 'alice')").Error)
+
+	// An error rolls back the migrations along with everything else
+	err = m.Transaction(ctx, func(tx *migrations.Tx) error {
+		_, err := tx.MigrateTo(0)
+		require.NoError(t, err)
+		return errors.New("restore failed")
+	})
+	assert.EqualError(t, err, "restore failed")
+	require.NoError(t, m.Check(ctx))
+	var count int64
+	require.NoError(t, db.Table("lists").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+
+	broken := fstest.MapFS{
+		"0001_create_lists.up.sql": testMigrations["0001_create_lists.up.sql"],
+		"0002_broken.up.sql":       {Data: []byte("CREATE TABLE tags (id INTEGER PRIMARY KEY);\nALTER TABLE missing ADD COLUMN x TEXT;")},
+	}
+	m, err := migrations.New(db, broken)
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	assert.ErrorContains(t, err, "migration 2 (broken) failed")
+	assert.Len(t, applied, 1)
+	// The failing migration was rolled back as a whole
+	assert.False(t, db.Migrator().HasTable("tags"))
+
+	var failed *migrations.FailedError
+	require.ErrorAs(t, m.Check(ctx), &failed)
+	assert.Equal(t, uint(2), failed.Record.Version)
+	assert.Contains(t, failed.Record.Error, "missing")
+
+	// Startup keeps refusing until the failure is cleared
+	_, err = m.Up(ctx)
+	require.ErrorAs(t, err, &failed)
+
+	broken["0002_broken.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE tags (id INTEGER PRIMARY KEY);")}
+	m, err = migrations.New(db, broken)
+	require.NoError(t, err)
+	require.NoError(t, m.ClearFailed(ctx))
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+	require.NoError(t, m.Check(ctx))
+}
+
+func TestNewerSchema(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.Open(t)
+	m, err := migrations.New(db, testMigrations)
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	older, err := migrations.New(db, fstest.MapFS{"0001_create_lists.up.sql": testMigrations["0001_create_lists.up.sql"]})
+	require.NoError(t, err)
+	assert.ErrorContains(t, older.Check(ctx), "newer than the latest migration")
+}
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    password TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS settings (
+    section TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    client VARCHAR(100) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    status INTEGER,
+    content_type VARCHAR(255),
+    e_tag VARCHAR(255),
+    location TEXT,
+    body BLOB,
+    created_at DATETIME,
+    expires_at DATETIME NOT NULL,
+    PRIMARY KEY (client, key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -1,159 +1,219 @@
 // models/config.go
 package models
 
-// Configuration represents the complete application configuration
+// Configuration represents the complete application configuration.
+// Fields tagged with secret:"true" hold credentials and are encrypted at rest
+// when a master key is configured.
 // @Description Complete application configuration settings
 type Configuration struct {
 	// App contains core application settings
 	App struct {
-		Name        string `json:"name" mapstructure:"name" example:"Listarr" binding:"required"`
-		Environment string `json:"environment" mapstructure:"environment" example:"development" binding:"required,oneof=development staging production"`
-		AppURL      string `json:"appURL" mapstructure:"appURL" example:"http://localhost:3000" binding:"required,url"`
-		APIBaseURL  string `json:"apiBaseURL" mapstructure:"apiBaseURL" example:"http://localhost:8080" binding:"required,url"`
-		LogLevel    string `json:"logLevel" mapstructure:"logLevel" example:"info" binding:"required,oneof=debug info warn error"`
-		MaxPageSize int    `json:"maxPageSize" mapstructure:"maxPageSize" example:"100" binding:"required,min=1,max=1000"`
-	} `json:"app"`
+		Name        string `json:"name" mapstructure:"name" example:"Listarr" binding:"required" description:"Display name of the application"`
+		Environment string `json:"environment" mapstructure:"environment" example:"development" binding:"required,oneof=development staging production" description:"Deployment environment"`
+		AppURL      string `json:"appURL" mapstructure:"appURL" example:"http://localhost:3000" binding:"required,url" description:"Public URL of the web frontend"`
+		APIBaseURL  string `json:"apiBaseURL" mapstructure:"apiBaseURL" example:"http://localhost:8080" binding:"required,url" description:"Public base URL of this API"`
+		LogLevel    string `json:"logLevel" mapstructure:"logLevel" example:"info" binding:"required,oneof=debug info warn error" description:"Minimum level of log messages to write"`
+		LogFormat   string `json:"logFormat" mapstructure:"logFormat" example:"text" binding:"required,oneof=text json" description:"Log output format, text for people or json for log collectors"`
+		MaxPageSize int    `json:"maxPageSize" mapstructure:"maxPageSize" example:"100" binding:"required,min=1,max=1000" description:"Largest page size accepted by list endpoints"`
+	} `json:"app" description:"Core application settings"`
+
+	// Settings selects where the mutable settings are stored. Like Db it is a
+	// bootstrap section and is always read from app.config.json or the environment.
+	Settings struct {
+		Backend string `json:"backend" mapstructure:"backend" example:"file" binding:"required,oneof=file database" description:"Where settings are stored: file keeps them in app.config.json, database shares them between instances"`
+	} `json:"settings" description:"Settings storage backend"`
 
 	// Database contains database connection settings
 	Db struct {
-		Host     string `json:"host" mapstructure:"url" example:"localhost" binding:"required"`
-		Port     string `json:"port" mapstructure:"port" example:"5432" binding:"required"`
-		Name     string `json:"name" mapstructure:"name" example:"listarr" binding:"required"`
-		User     string `json:"user" mapstructure:"user" example:"postgres_user" binding:"required"`
-		Password string `json:"password" mapstructure:"password" example:"yourpassword" binding:"required"`
-		MaxConns int    `json:"maxConns" mapstructure:"maxConns" example:"20" binding:"required,min=1"`
-		Timeout  int    `json:"timeout" mapstructure:"timeout" example:"30" binding:"required,min=1"`
-	} `json:"db" mapstructure:"db"`
+		Driver          string `json:"driver" mapstructure:"driver" example:"postgres" binding:"required,oneof=postgres sqlite" description:"Database engine: postgres, or sqlite for a single database file without a database server"`
+		Path            string `json:"path" mapstructure:"path" example:"config/listarr.db" binding:"required_if=Driver sqlite" description:"SQLite database file, used when driver is sqlite"`
+		Host            string `json:"host" mapstructure:"url" example:"localhost" binding:"required_if=Driver postgres" description:"Database server hostname"`
+		Port            string `json:"port" mapstructure:"port" example:"5432" binding:"required_if=Driver postgres" description:"Database server port"`
+		Name            string `json:"name" mapstructure:"name" example:"listarr" binding:"required_if=Driver postgres" description:"Database name"`
+		User            string `json:"user" mapstructure:"user" example:"postgres_user" binding:"required_if=Driver postgres" description:"Database user"`
+		Password        string `json:"password" mapstructure:"password" example:"yourpassword" binding:"required_if=Driver postgres" secret:"true" description:"Database password"`
+		SSLMode         string `json:"sslMode" mapstructure:"sslMode" example:"verify-full" binding:"omitempty,oneof=disable allow prefer require verify-ca verify-full" description:"Postgres TLS mode; verify-ca and verify-full check the server certificate"`
+		SSLRootCert     string `json:"sslRootCert" mapstructure:"sslRootCert" example:"/etc/listarr/db-ca.pem" description:"PEM file with the CA certificates trusted for the Postgres server, instead of the system roots"`
+		MaxConns        int    `json:"maxConns" mapstructure:"maxConns" example:"20" binding:"required,min=1" description:"Maximum number of open database connections"`
+		MaxIdleConns    int    `json:"maxIdleConns" mapstructure:"maxIdleConns" example:"5" binding:"min=0" description:"Maximum number of idle connections kept open, at most maxConns"`
+		ConnMaxLifetime int    `json:"connMaxLifetime" mapstructure:"connMaxLifetime" example:"1800" binding:"min=0" description:"Seconds after which a connection is closed and replaced, 0 to keep connections indefinitely"`
+		ConnMaxIdleTime int    `json:"connMaxIdleTime" mapstructure:"connMaxIdleTime" example:"300" binding:"min=0" description:"Seconds an idle connection is kept before it is closed, 0 to keep idle connections indefinitely"`
+		Timeout         int    `json:"timeout" mapstructure:"timeout" example:"30" binding:"required,min=1" description:"Seconds a database query may run, also the connect timeout and how long SQLite waits for a locked database"`
+		StartupTimeout  int    `json:"startupTimeout" mapstructure:"startupTimeout" example:"60" binding:"min=0" description:"Seconds to keep retrying with backoff when the database is unreachable at startup, 0 to fail at once"`
+		MigrateOnStart  bool   `json:"migrateOnStart" mapstructure:"migrateOnStart" example:"true" description:"Apply pending database migrations at startup instead of requiring the migrate up command"`
+	} `json:"db" mapstructure:"db" description:"Database connection settings"`
 
 	// HTTP contains HTTP server configuration
 	HTTP struct {
-		Port             string `json:"port" mapstructure:"port" example:"8080" binding:"required"`
-		ReadTimeout      int    `json:"readTimeout" mapstructure:"readTimeout" example:"30" binding:"required,min=1"`
-		WriteTimeout     int    `json:"writeTimeout" mapstructure:"writeTimeout" example:"30" binding:"required,min=1"`
-		IdleTimeout      int    `json:"idleTimeout" mapstructure:"idleTimeout" example:"60" binding:"required,min=1"`
-		EnableSSL        bool   `json:"enableSSL" mapstructure:"enableSSL" example:"false"`
-		SSLCert          string `json:"sslCert" mapstructure:"sslCert" example:"/path/to/cert.pem"`
-		SSLKey           string `json:"sslKey" mapstructure:"sslKey" example:"/path/to/key.pem"`
-		ProxyEnabled     bool   `json:"proxyEnabled" mapstructure:"proxyEnabled" example:"false"`
-		ProxyURL         string `json:"proxyURL" mapstructure:"proxyURL" example:"http://proxy:8080"`
-		RateLimitEnabled bool   `json:"rateLimitEnabled" mapstructure:"rateLimitEnabled" example:"true"`
-		RequestsPerMin   int    `json:"requestsPerMin" mapstructure:"requestsPerMin" example:"100" binding:"min=0"`
-	} `json:"http"`
+		Port               string   `json:"port" mapstructure:"port" example:"8080" binding:"required" description:"Port the API server listens on"`
+		URLBase            string   `json:"urlBase" mapstructure:"urlBase" example:"/listarr" binding:"omitempty,startswith=/" description:"Path prefix every route is served under when hosted in a subpath behind a reverse proxy; changes apply after a restart"`
+		TrustedProxies     []string `json:"trustedProxies" mapstructure:"trustedProxies" example:"127.0.0.1,10.0.0.0/8" binding:"dive,ip|cidr" description:"IPs and CIDR ranges of reverse proxies whose X-Forwarded-For, X-Forwarded-Proto and X-Forwarded-Prefix headers are trusted; changes apply after a restart"`
+		ReadTimeout        int      `json:"readTimeout" mapstructure:"readTimeout" example:"30" binding:"required,min=1" description:"Maximum time in seconds to read a request"`
+		WriteTimeout       int      `json:"writeTimeout" mapstructure:"writeTimeout" example:"30" binding:"required,min=1" description:"Maximum time in seconds to write a response"`
+		IdleTimeout        int      `json:"idleTimeout" mapstructure:"idleTimeout" example:"60" binding:"required,min=1" description:"Maximum time in seconds to keep idle connections open"`
+		ShutdownTimeout    int      `json:"shutdownTimeout" mapstructure:"shutdownTimeout" example:"30" binding:"required,min=1" description:"Maximum time in seconds to wait for requests and background work to finish on shutdown"`
+		EnableSSL          bool     `json:"enableSSL" mapstructure:"enableSSL" example:"false" description:"Serve the API over HTTPS"`
+		SSLCert            string   `json:"sslCert" mapstructure:"sslCert" example:"/path/to/cert.pem" description:"Path to the TLS certificate file"`
+		SSLKey             string   `json:"sslKey" mapstructure:"sslKey" example:"/path/to/key.pem" description:"Path to the TLS private key file"`
+		ProxyEnabled       bool     `json:"proxyEnabled" mapstructure:"proxyEnabled" example:"false" description:"Route requests to integrations through a proxy"`
+		ProxyURL           string   `json:"proxyURL" mapstructure:"proxyURL" example:"http://proxy:8080" binding:"required_if=ProxyEnabled true" description:"Outbound proxy URL for integrations, http://, https:// or socks5://"`
+		RateLimitEnabled   bool     `json:"rateLimitEnabled" mapstructure:"rateLimitEnabled" example:"true" description:"Limit the number of requests per client"`
+		RequestsPerMin     int      `json:"requestsPerMin" mapstructure:"requestsPerMin" example:"100" binding:"min=0" description:"Requests allowed per client each minute, 0 for no limit"`
+		AuthRequestsPerMin int      `json:"authRequestsPerMin" mapstructure:"authRequestsPerMin" example:"10" binding:"min=0" description:"Requests allowed per client each minute on authentication endpoints, 0 for no limit"`
+		RequireIfMatch     bool     `json:"requireIfMatch" mapstructure:"requireIfMatch" example:"false" description:"Reject updates and deletes of users and settings sent without an If-Match header with 428"`
+		IdempotencyWindow  int      `json:"idempotencyWindow" mapstructure:"idempotencyWindow" example:"24" binding:"min=0" description:"Hours the response to a POST sent with an Idempotency-Key header is replayed to retries, 0 to ignore the header"`
+	} `json:"http" description:"HTTP server settings"`
 
 	// Auth contains authentication settings
 	Auth struct {
-		EnableLocal     bool     `json:"enableLocal" mapstructure:"enableLocal" example:"true"`
-		SessionTimeout  int      `json:"sessionTimeout" mapstructure:"sessionTimeout" example:"60" binding:"required,min=1"`
-		Enable2FA       bool     `json:"enable2FA" mapstructure:"enable2FA" example:"false"`
-		JWTSecret       string   `json:"jwtSecret" mapstructure:"jwtSecret" example:"your-secret-key" binding:"required"`
-		TokenExpiration int      `json:"tokenExpiration" mapstructure:"tokenExpiration" example:"24" binding:"required,min=1"`
-		AllowedOrigins  []string `json:"allowedOrigins" mapstructure:"allowedOrigins" example:"http://localhost:3000"`
-	} `json:"auth"`
+		EnableLocal     bool     `json:"enableLocal" mapstructure:"enableLocal" example:"true" description:"Allow sign in with local accounts"`
+		SessionTimeout  int      `json:"sessionTimeout" mapstructure:"sessionTimeout" example:"60" binding:"required,min=1" description:"Session inactivity timeout in minutes"`
+		Enable2FA       bool     `json:"enable2FA" mapstructure:"enable2FA" example:"false" description:"Require two-factor authentication"`
+		JWTSecret       string   `json:"jwtSecret" mapstructure:"jwtSecret" example:"your-secret-key" binding:"required" secret:"true" description:"Secret used to sign JWT tokens"`
+		TokenExpiration int      `json:"tokenExpiration" mapstructure:"tokenExpiration" example:"24" binding:"required,min=1" description:"Token lifetime in hours"`
+		AllowedOrigins  []string `json:"allowedOrigins" mapstructure:"allowedOrigins" example:"http://localhost:3000" description:"Origins allowed to make cross-origin requests in addition to appURL; https://*.example.com matches any subdomain"`
+		APIKey          string   `json:"apiKey" mapstructure:"apiKey" example:"your-admin-api-key" secret:"true" description:"Key expected in the X-Api-Key header on admin endpoints such as /system/info; admin endpoints are disabled while empty"`
+	} `json:"auth" description:"Authentication settings"`
 
 	// Integrations contains all third-party service configurations
 	Integrations struct {
-		Emby      EmbyConfig      `json:"emby" mapstructure:"emby"`
-		Jellyfin  JellyfinConfig  `json:"jellyfin" mapstructure:"jellyfin"`
-		Plex      PlexConfig      `json:"plex" mapstructure:"plex"`
-		Trakt     TraktConfig     `json:"trakt" mapstructure:"trakt"`
-		Navidrome NavidromeConfig `json:"navidrome" mapstructure:"navidrome"`
-		Spotify   SpotifyConfig   `json:"spotify" mapstructure:"spotify"`
-	} `json:"integrations"`
+		Emby      EmbyConfig      `json:"emby" mapstructure:"emby" description:"Emby media server settings"`
+		Jellyfin  JellyfinConfig  `json:"jellyfin" mapstructure:"jellyfin" description:"Jellyfin media server settings"`
+		Plex      PlexConfig      `json:"plex" mapstructure:"plex" description:"Plex media server settings"`
+		Trakt     TraktConfig     `json:"trakt" mapstructure:"trakt" description:"Trakt.tv settings"`
+		Navidrome NavidromeConfig `json:"navidrome" mapstructure:"navidrome" description:"Navidrome music server settings"`
+		Spotify   SpotifyConfig   `json:"spotify" mapstructure:"spotify" description:"Spotify settings"`
+	} `json:"integrations" description:"Third-party service settings"`
 
 	// Sync contains synchronization settings
 	Sync struct {
-		Enabled          bool   `json:"enabled" mapstructure:"enabled" example:"true"`
-		Interval         string `json:"interval" mapstructure:"interval" example:"0 */12 * * *" binding:"required"`
-		ConflictStrategy string `json:"conflictStrategy" mapstructure:"conflictStrategy" example:"skip" binding:"required,oneof=overwrite skip merge"`
+		Enabled          bool   `json:"enabled" mapstructure:"enabled" example:"true" description:"Enable scheduled synchronization"`
+		Interval         string `json:"interval" mapstructure:"interval" example:"0 */12 * * *" binding:"required" description:"Cron schedule for synchronization"`
+		ConflictStrategy string `json:"conflictStrategy" mapstructure:"conflictStrategy" example:"skip" binding:"required,oneof=overwrite skip merge" description:"How to resolve items changed on both sides"`
 
 		Playlists struct {
-			EnableSync   bool     `json:"enableSync" mapstructure:"enableSync" example:"true"`
-			SyncInterval string   `json:"syncInterval" mapstructure:"syncInterval" example:"0 */6 * * *"`
-			AllowedTypes []string `json:"allowedTypes" mapstructure:"allowedTypes" example:"music,media"`
-			MaxItems     int      `json:"maxItems" mapstructure:"maxItems" example:"1000" binding:"required,min=1"`
-		} `json:"playlists"`
+			EnableSync   bool     `json:"enableSync" mapstructure:"enableSync" example:"true" description:"Synchronize playlists"`
+			SyncInterval string   `json:"syncInterval" mapstructure:"syncInterval" example:"0 */6 * * *" description:"Cron schedule for playlist synchronization"`
+			AllowedTypes []string `json:"allowedTypes" mapstructure:"allowedTypes" example:"music,media" description:"Playlist types to synchronize"`
+			MaxItems     int      `json:"maxItems" mapstructure:"maxItems" example:"1000" binding:"required,min=1" description:"Maximum number of items per playlist"`
+		} `json:"playlists" description:"Playlist synchronization settings"`
 
 		Collections struct {
-			EnableSync   bool     `json:"enableSync" mapstructure:"enableSync" example:"true"`
-			SyncInterval string   `json:"syncInterval" mapstructure:"syncInterval" example:"0 */12 * * *"`
-			AllowedTypes []string `json:"allowedTypes" mapstructure:"allowedTypes" example:"series,movies,music"`
-			MaxItems     int      `json:"maxItems" mapstructure:"maxItems" example:"5000" binding:"required,min=1"`
-		} `json:"collections"`
-	} `json:"sync"`
+			EnableSync   bool     `json:"enableSync" mapstructure:"enableSync" example:"true" description:"Synchronize collections"`
+			SyncInterval string   `json:"syncInterval" mapstructure:"syncInterval" example:"0 */12 * * *" description:"Cron schedule for collection synchronization"`
+			AllowedTypes []string `json:"allowedTypes" mapstructure:"allowedTypes" example:"series,movies,music" description:"Collection types to synchronize"`
+			MaxItems     int      `json:"maxItems" mapstructure:"maxItems" example:"5000" binding:"required,min=1" description:"Maximum number of items per collection"`
+		} `json:"collections" description:"Collection synchronization settings"`
+	} `json:"sync" description:"Synchronization settings"`
 
 	// SpotDL contains Spotify download integration settings
 	SpotDL struct {
-		Enabled          bool   `json:"enabled" mapstructure:"enabled" example:"false"`
-		DownloadDir      string `json:"downloadDirectory" mapstructure:"downloadDirectory" example:"./downloads" binding:"required"`
-		FileFormat       string `json:"fileFormat" mapstructure:"fileFormat" example:"mp3" binding:"required,oneof=mp3 flac"`
-		QualityPreset    string `json:"qualityPreset" mapstructure:"qualityPreset" example:"high" binding:"required,oneof=low medium high"`
-		NamingTemplate   string `json:"namingTemplate" mapstructure:"namingTemplate" example:"{artist} - {title}" binding:"required"`
-		MaxRetries       int    `json:"maxRetries" mapstructure:"maxRetries" example:"3" binding:"required,min=0"`
-		ConcurrentLimit  int    `json:"concurrentDownloads" mapstructure:"concurrentDownloads" example:"2" binding:"required,min=1"`
-		NotifyOnComplete bool   `json:"notifyOnComplete" mapstructure:"notifyOnComplete" example:"true"`
-	} `json:"spotdl"`
+		Enabled          bool   `json:"enabled" mapstructure:"enabled" example:"false" description:"Enable downloads through SpotDL"`
+		DownloadDir      string `json:"downloadDirectory" mapstructure:"downloadDirectory" example:"./downloads" binding:"required" description:"Directory downloaded files are written to"`
+		FileFormat       string `json:"fileFormat" mapstructure:"fileFormat" example:"mp3" binding:"required,oneof=mp3 flac" description:"Audio format of downloaded files"`
+		QualityPreset    string `json:"qualityPreset" mapstructure:"qualityPreset" example:"high" binding:"required,oneof=low medium high" description:"Audio quality of downloaded files"`
+		NamingTemplate   string `json:"namingTemplate" mapstructure:"namingTemplate" example:"{artist} - {title}" binding:"required" description:"File name template for downloaded tracks"`
+		MaxRetries       int    `json:"maxRetries" mapstructure:"maxRetries" example:"3" binding:"required,min=0" description:"Download attempts before giving up"`
+		ConcurrentLimit  int    `json:"concurrentDownloads" mapstructure:"concurrentDownloads" example:"2" binding:"required,min=1" description:"Number of simultaneous downloads"`
+		NotifyOnComplete bool   `json:"notifyOnComplete" mapstructure:"notifyOnComplete" example:"true" description:"Send a notification when a download finishes"`
+	} `json:"spotdl" description:"SpotDL download settings"`
+
+	// Observability contains tracing settings, read at startup
+	Observability struct {
+		TracingEnabled bool    `json:"tracingEnabled" mapstructure:"tracingEnabled" example:"false" description:"Record OpenTelemetry traces of requests, database queries and integration calls; changes apply after a restart"`
+		Exporter       string  `json:"exporter" mapstructure:"exporter" example:"otlp" binding:"required,oneof=otlp stdout" description:"Where traces are sent: otlp to an OTLP/HTTP collector or stdout for debugging"`
+		OTLPEndpoint   string  `json:"otlpEndpoint" mapstructure:"otlpEndpoint" example:"localhost:4318" binding:"required_if=Exporter otlp" description:"host:port of the OTLP/HTTP collector"`
+		OTLPInsecure   bool    `json:"otlpInsecure" mapstructure:"otlpInsecure" example:"true" description:"Send traces to the collector over plain HTTP"`
+		ServiceName    string  `json:"serviceName" mapstructure:"serviceName" example:"listarr-backend" binding:"required" description:"service.name reported with every span"`
+		SampleRatio    float64 `json:"sampleRatio" mapstructure:"sampleRatio" example:"1" binding:"min=0,max=1" description:"Fraction of new traces to record, from 0 to 1; traces started upstream follow the caller's decision"`
+	} `json:"observability" description:"Tracing settings"`
+
+	// Frontend controls serving the web frontend from this server, read at startup
+	Frontend struct {
+		Enabled bool   `json:"enabled" mapstructure:"enabled" example:"true" description:"Serve the web frontend from this server when a build is embedded or dir is set; changes apply after a restart"`
+		Dir     string `json:"dir" mapstructure:"dir" example:"/srv/listarr/web" description:"Directory holding a frontend build to serve instead of the one embedded in the binary"`
+	} `json:"frontend" description:"Web frontend settings"`
+
+	// Backup controls the scheduled backups of the database and configuration
+	Backup struct {
+		Dir            string `json:"dir" mapstructure:"dir" example:"config/backups" binding:"required" description:"Directory scheduled backups are written to"`
+		Interval       int    `json:"interval" mapstructure:"interval" example:"24" binding:"min=0" description:"Hours between scheduled backups, 0 to disable them"`
+		Retention      int    `json:"retention" mapstructure:"retention" example:"7" binding:"min=1" description:"Number of scheduled backups kept in dir; older ones are deleted"`
+		ExcludeSecrets bool   `json:"excludeSecrets" mapstructure:"excludeSecrets" example:"false" description:"Leave passwords, tokens and API keys out of scheduled backups; restoring one keeps the current values"`
+	} `json:"backup" description:"Scheduled backup settings"`
 }
 
 // Integration config types
 // @Description Emby media server configuration
 type EmbyConfig struct {
-	Enabled  bool   `json:"enabled" mapstructure:"enabled" example:"false"`
-	Host     string `json:"host" mapstructure:"host" example:"localhost" binding:"required_if=Enabled true"`
-	Port     int    `json:"port" mapstructure:"port" example:"8096" binding:"required_if=Enabled true"`
-	APIKey   string `json:"apiKey" mapstructure:"apiKey" example:"your-api-key" binding:"required_if=Enabled true"`
-	Username string `json:"username" mapstructure:"username" example:"admin"`
-	SSL      bool   `json:"ssl" mapstructure:"ssl" example:"false"`
+	Enabled         bool   `json:"enabled" mapstructure:"enabled" example:"false" description:"Enable the Emby integration"`
+	Host            string `json:"host" mapstructure:"host" example:"localhost" binding:"required_if=Enabled true" description:"Emby server hostname"`
+	Port            int    `json:"port" mapstructure:"port" example:"8096" binding:"required_if=Enabled true" description:"Emby server port"`
+	APIKey          string `json:"apiKey" mapstructure:"apiKey" example:"your-api-key" binding:"required_if=Enabled true" secret:"true" description:"Emby API key"`
+	Username        string `json:"username" mapstructure:"username" example:"admin" description:"Emby username"`
+	SSL             bool   `json:"ssl" mapstructure:"ssl" example:"false" description:"Connect to Emby over HTTPS"`
+	BypassProxy     bool   `json:"bypassProxy" mapstructure:"bypassProxy" example:"false" description:"Connect to Emby directly even when the outbound proxy is enabled"`
+	CACertFile      string `json:"caCertFile" mapstructure:"caCertFile" example:"/config/certs/ca.pem" description:"PEM bundle of extra certificate authorities trusted for Emby"`
+	IgnoreTLSErrors bool   `json:"ignoreTLSErrors" mapstructure:"ignoreTLSErrors" example:"false" description:"Skip certificate verification for Emby, for self-signed certificates"`
+	Required        bool   `json:"required" mapstructure:"required" example:"false" description:"Report the service as not ready while Emby is enabled and unreachable"`
 }
 
 // @Description Jellyfin media server configuration
 type JellyfinConfig struct {
-	Enabled  bool   `json:"enabled" mapstructure:"enabled" example:"false"`
-	Host     string `json:"host" mapstructure:"host" example:"localhost" binding:"required_if=Enabled true"`
-	Port     int    `json:"port" mapstructure:"port" example:"8096" binding:"required_if=Enabled true"`
-	APIKey   string `json:"apiKey" mapstructure:"apiKey" example:"your-api-key" binding:"required_if=Enabled true"`
-	Username string `json:"username" mapstructure:"username" example:"admin"`
-	SSL      bool   `json:"ssl" mapstructure:"ssl" example:"false"`
+	Enabled         bool   `json:"enabled" mapstructure:"enabled" example:"false" description:"Enable the Jellyfin integration"`
+	Host            string `json:"host" mapstructure:"host" example:"localhost" binding:"required_if=Enabled true" description:"Jellyfin server hostname"`
+	Port            int    `json:"port" mapstructure:"port" example:"8096" binding:"required_if=Enabled true" description:"Jellyfin server port"`
+	APIKey          string `json:"apiKey" mapstructure:"apiKey" example:"your-api-key" binding:"required_if=Enabled true" secret:"true" description:"Jellyfin API key"`
+	Username        string `json:"username" mapstructure:"username" example:"admin" description:"Jellyfin username"`
+	SSL             bool   `json:"ssl" mapstructure:"ssl" example:"false" description:"Connect to Jellyfin over HTTPS"`
+	BypassProxy     bool   `json:"bypassProxy" mapstructure:"bypassProxy" example:"false" description:"Connect to Jellyfin directly even when the outbound proxy is enabled"`
+	CACertFile      string `json:"caCertFile" mapstructure:"caCertFile" example:"/config/certs/ca.pem" description:"PEM bundle of extra certificate authorities trusted for Jellyfin"`
+	IgnoreTLSErrors bool   `json:"ignoreTLSErrors" mapstructure:"ignoreTLSErrors" example:"false" description:"Skip certificate verification for Jellyfin, for self-signed certificates"`
+	Required        bool   `json:"required" mapstructure:"required" example:"false" description:"Report the service as not ready while Jellyfin is enabled and unreachable"`
 }
 
 // @Description Plex media server configuration
 type PlexConfig struct {
-	Enabled bool   `json:"enabled" mapstructure:"enabled" example:"false"`
-	Host    string `json:"host" mapstructure:"host" example:"localhost" binding:"required_if=Enabled true"`
-	Port    int    `json:"port" mapstructure:"port" example:"32400" binding:"required_if=Enabled true"`
-	Token   string `json:"token" mapstructure:"token" example:"your-plex-token" binding:"required_if=Enabled true"`
-	SSL     bool   `json:"ssl" mapstructure:"ssl" example:"false"`
+	Enabled         bool   `json:"enabled" mapstructure:"enabled" example:"false" description:"Enable the Plex integration"`
+	Host            string `json:"host" mapstructure:"host" example:"localhost" binding:"required_if=Enabled true" description:"Plex server hostname"`
+	Port            int    `json:"port" mapstructure:"port" example:"32400" binding:"required_if=Enabled true" description:"Plex server port"`
+	Token           string `json:"token" mapstructure:"token" example:"your-plex-token" binding:"required_if=Enabled true" secret:"true" description:"Plex authentication token"`
+	SSL             bool   `json:"ssl" mapstructure:"ssl" example:"false" description:"Connect to Plex over HTTPS"`
+	BypassProxy     bool   `json:"bypassProxy" mapstructure:"bypassProxy" example:"false" description:"Connect to Plex directly even when the outbound proxy is enabled"`
+	CACertFile      string `json:"caCertFile" mapstructure:"caCertFile" example:"/config/certs/ca.pem" description:"PEM bundle of extra certificate authorities trusted for Plex"`
+	IgnoreTLSErrors bool   `json:"ignoreTLSErrors" mapstructure:"ignoreTLSErrors" example:"false" description:"Skip certificate verification for Plex, for self-signed certificates"`
+	Required        bool   `json:"required" mapstructure:"required" example:"false" description:"Report the service as not ready while Plex is enabled and unreachable"`
 }
 
 // @Description Trakt.tv configuration
 type TraktConfig struct {
-	Enabled      bool   `json:"enabled" mapstructure:"enabled" example:"false"`
-	ClientID     string `json:"clientId" mapstructure:"clientId" example:"your-client-id" binding:"required_if=Enabled true"`
-	ClientSecret string `json:"clientSecret" mapstructure:"clientSecret" example:"your-client-secret" binding:"required_if=Enabled true"`
-	RedirectURI  string `json:"redirectUri" mapstructure:"redirectUri" example:"http://localhost:8080/callback" binding:"required_if=Enabled true"`
+	Enabled      bool   `json:"enabled" mapstructure:"enabled" example:"false" description:"Enable the Trakt integration"`
+	ClientID     string `json:"clientId" mapstructure:"clientId" example:"your-client-id" binding:"required_if=Enabled true" description:"Trakt application client ID"`
+	ClientSecret string `json:"clientSecret" mapstructure:"clientSecret" example:"your-client-secret" binding:"required_if=Enabled true" secret:"true" description:"Trakt application client secret"`
+	RedirectURI  string `json:"redirectUri" mapstructure:"redirectUri" example:"http://localhost:8080/callback" binding:"required_if=Enabled true" description:"OAuth redirect URI registered with Trakt"`
+	BypassProxy  bool   `json:"bypassProxy" mapstructure:"bypassProxy" example:"false" description:"Connect to Trakt directly even when the outbound proxy is enabled"`
 }
 
 // @Description Navidrome music server configuration
 type NavidromeConfig struct {
-	Enabled  bool   `json:"enabled" mapstructure:"enabled" example:"false"`
-	Host     string `json:"host" mapstructure:"host" example:"localhost" binding:"required_if=Enabled true"`
-	Port     int    `json:"port" mapstructure:"port" example:"4533" binding:"required_if=Enabled true"`
-	Username string `json:"username" mapstructure:"username" example:"admin" binding:"required_if=Enabled true"`
-	Password string `json:"password" mapstructure:"password" example:"your-password" binding:"required_if=Enabled true"`
-	SSL      bool   `json:"ssl" mapstructure:"ssl" example:"false"`
+	Enabled         bool   `json:"enabled" mapstructure:"enabled" example:"false" description:"Enable the Navidrome integration"`
+	Host            string `json:"host" mapstructure:"host" example:"localhost" binding:"required_if=Enabled true" description:"Navidrome server hostname"`
+	Port            int    `json:"port" mapstructure:"port" example:"4533" binding:"required_if=Enabled true" description:"Navidrome server port"`
+	Username        string `json:"username" mapstructure:"username" example:"admin" binding:"required_if=Enabled true" description:"Navidrome username"`
+	Password        string `json:"password" mapstructure:"password" example:"your-password" binding:"required_if=Enabled true" secret:"true" description:"Navidrome password"`
+	SSL             bool   `json:"ssl" mapstructure:"ssl" example:"false" description:"Connect to Navidrome over HTTPS"`
+	BypassProxy     bool   `json:"bypassProxy" mapstructure:"bypassProxy" example:"false" description:"Connect to Navidrome directly even when the outbound proxy is enabled"`
+	CACertFile      string `json:"caCertFile" mapstructure:"caCertFile" example:"/config/certs/ca.pem" description:"PEM bundle of extra certificate authorities trusted for Navidrome"`
+	IgnoreTLSErrors bool   `json:"ignoreTLSErrors" mapstructure:"ignoreTLSErrors" example:"false" description:"Skip certificate verification for Navidrome, for self-signed certificates"`
+	Required        bool   `json:"required" mapstructure:"required" example:"false" description:"Report the service as not ready while Navidrome is enabled and unreachable"`
 }
 
 // @Description Spotify configuration
 type SpotifyConfig struct {
-	Enabled      bool   `json:"enabled" mapstructure:"enabled" example:"false"`
-	ClientID     string `json:"clientId" mapstructure:"clientId" example:"your-client-id" binding:"required_if=Enabled true"`
-	ClientSecret string `json:"clientSecret" mapstructure:"clientSecret" example:"your-client-secret" binding:"required_if=Enabled true"`
-	RedirectURI  string `json:"redirectUri" mapstructure:"redirectUri" example:"http://localhost:8080/callback" binding:"required_if=Enabled true"`
-	Scopes       string `json:"scopes" mapstructure:"scopes" example:"user-library-read playlist-read-private"`
-}
-
-// ConfigResponse represents the response structure for configuration endpoints
-// @Description Configuration response wrapper
-type ConfigResponse struct {
-	Data  *Configuration `json:"data,omitempty"`
-	Error string         `json:"error,omitempty"`
+	Enabled      bool   `json:"enabled" mapstructure:"enabled" example:"false" description:"Enable the Spotify integration"`
+	ClientID     string `json:"clientId" mapstructure:"clientId" example:"your-client-id" binding:"required_if=Enabled true" description:"Spotify application client ID"`
+	ClientSecret string `json:"clientSecret" mapstructure:"clientSecret" example:"your-client-secret" binding:"required_if=Enabled true" secret:"true" description:"Spotify application client secret"`
+	RedirectURI  string `json:"redirectUri" mapstructure:"redirectUri" example:"http://localhost:8080/callback" binding:"required_if=Enabled true" description:"OAuth redirect URI registered with Spotify"`
+	Scopes       string `json:"scopes" mapstructure:"scopes" example:"user-library-read playlist-read-private" description:"OAuth scopes requested from Spotify"`
+	BypassProxy  bool   `json:"bypassProxy" mapstructure:"bypassProxy" example:"false" description:"Connect to Spotify directly even when the outbound proxy is enabled"`
 }
@@ -0,0 +1,22 @@
+// models/idempotency.go
+package models
+
+import "time"
+
+// IdempotencyKey stores the first response to a request sent with an
+// Idempotency-Key header so retries of the request get the same response
+type IdempotencyKey struct {
+	// Client identifies the caller, so keys from different clients never collide
+	Client string `gorm:"primaryKey;size:100"`
+	Key    string `gorm:"primaryKey;size:255"`
+	// Fingerprint is a hash of the method, path and body of the first request
+	Fingerprint string `gorm:"size:64;not null"`
+	// Status is zero while the first request is still being handled
+	Status      int
+	ContentType string `gorm:"size:255"`
+	ETag        string `gorm:"size:255"`
+	Location    string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index;not null"`
+}
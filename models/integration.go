@@ -0,0 +1,24 @@
+// models/integration.go
+package models
+
+// Failure categories reported by integration connection tests
+const (
+	FailureInvalidConfig = "invalid_config"
+	FailureDNS           = "dns"
+	FailureConnection    = "connection"
+	FailureTLS           = "tls"
+	FailureTimeout       = "timeout"
+	FailureAuth          = "auth"
+	FailureResponse      = "response"
+)
+
+// IntegrationTestResult represents the outcome of an integration connection test
+// @Description Result of probing an integration with the supplied settings
+type IntegrationTestResult struct {
+	Success    bool   `json:"success" example:"true"`
+	ServerName string `json:"serverName,omitempty" example:"Living Room"`
+	Version    string `json:"version,omitempty" example:"10.8.13"`
+	LatencyMs  int64  `json:"latencyMs" example:"42"`
+	Failure    string `json:"failure,omitempty" example:"auth" enums:"invalid_config,dns,connection,tls,timeout,auth,response"`
+	Error      string `json:"error,omitempty" example:"server rejected the credentials"`
+}
@@ -1,14 +1,62 @@
 // models/responses.go
 package models
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error string `json:"error" example:"error message"`
+// Stable problem codes. Clients should branch on these rather than on the
+// human readable title or detail.
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeValidationFailed     = "validation_failed"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePreconditionRequired = "precondition_required"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeRateLimited          = "rate_limited"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeConfigUnavailable    = "config_unavailable"
+	CodeInternal             = "internal_error"
+)
+
+// ProblemTypePrefix starts the type URI of every problem, followed by the code
+const ProblemTypePrefix = "urn:listarr:problem:"
+
+// APIResponse is the envelope of every successful response
+// @Description Successful response wrapping the requested data
+type APIResponse[T any] struct {
+	Success bool      `json:"success" example:"true"`
+	Message string    `json:"message,omitempty" example:"Operation successful"`
+	Data    T         `json:"data"`
+	Meta    *PageMeta `json:"meta,omitempty"`
+}
+
+// PageMeta describes the page returned by a list endpoint
+// @Description Paging information for list responses
+type PageMeta struct {
+	Total      int64  `json:"total" example:"120"`
+	Limit      int    `json:"limit" example:"50"`
+	Offset     int    `json:"offset" example:"0"`
+	NextCursor string `json:"nextCursor,omitempty" example:"eyJzIjoiaWQiLCJ2IjpbNTBdfQ"`
+}
+
+// Problem is an RFC 7807 problem details error, sent as application/problem+json
+// @Description Error response following RFC 7807 with a stable machine readable code
+type Problem struct {
+	Type      string       `json:"type" example:"urn:listarr:problem:not_found"`
+	Title     string       `json:"title" example:"Not Found"`
+	Status    int          `json:"status" example:"404"`
+	Detail    string       `json:"detail,omitempty" example:"User not found"`
+	Instance  string       `json:"instance,omitempty" example:"/api/v1/users/42"`
+	Code      string       `json:"code" example:"not_found" enums:"invalid_request,validation_failed,not_found,conflict,idempotency_key_reused,precondition_failed,precondition_required,unauthorized,forbidden,rate_limited,payload_too_large,config_unavailable,internal_error"`
+	RequestID string       `json:"requestId,omitempty" example:"4f1c2a9be0d34e7c9a1b2c3d4e5f6a7b"`
+	Errors    []FieldError `json:"errors,omitempty"`
 }
 
-// APIResponse represents a generic API response
-type APIResponse struct {
-	Success bool        `json:"success" example:"true"`
-	Message string      `json:"message,omitempty" example:"Operation successful"`
-	Data    interface{} `json:"data,omitempty"`
+// FieldError is one failed validation rule
+// @Description A field that failed validation
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Rule    string `json:"rule,omitempty" example:"email"`
+	Message string `json:"message" example:"must be a valid email address"`
 }
@@ -0,0 +1,12 @@
+// models/setting.go
+package models
+
+import "time"
+
+// Setting stores one top-level configuration section as JSON when the
+// database settings backend is enabled
+type Setting struct {
+	Section   string    `json:"section" gorm:"primaryKey" example:"integrations"`
+	Value     string    `json:"value" gorm:"type:text;not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
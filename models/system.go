@@ -0,0 +1,65 @@
+// models/system.go
+package models
+
+// Component and overall statuses reported by health endpoints
+const (
+	StatusOK          = "ok"
+	StatusError       = "error"
+	StatusUnavailable = "unavailable"
+)
+
+// ComponentStatus is the health of one dependency
+// @Description Health of a single dependency checked for readiness
+type ComponentStatus struct {
+	Status    string `json:"status" example:"ok" enums:"ok,error"`
+	LatencyMs int64  `json:"latencyMs,omitempty" example:"3"`
+	Error     string `json:"error,omitempty" example:"connection refused"`
+}
+
+// HealthResponse is returned by the liveness and readiness endpoints
+// @Description Overall service health with the status of each checked dependency
+type HealthResponse struct {
+	Status     string                     `json:"status" example:"ok" enums:"ok,unavailable"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+// SystemInfo describes the running build and process
+// @Description Build, uptime, runtime and database pool information
+type SystemInfo struct {
+	Version       string       `json:"version" example:"1.2.0"`
+	Commit        string       `json:"commit" example:"3f2c1a9"`
+	BuildDate     string       `json:"buildDate,omitempty" example:"2024-01-01T00:00:00Z"`
+	Environment   string       `json:"environment" example:"production"`
+	StartedAt     string       `json:"startedAt" example:"2024-01-01T12:00:00Z"`
+	UptimeSeconds int64        `json:"uptimeSeconds" example:"3600"`
+	Runtime       RuntimeStats `json:"runtime"`
+	Database      DBPoolStats  `json:"database"`
+}
+
+// RuntimeStats holds Go runtime figures
+// @Description Go runtime statistics
+type RuntimeStats struct {
+	GoVersion     string `json:"goVersion" example:"go1.23.4"`
+	OS            string `json:"os" example:"linux"`
+	Arch          string `json:"arch" example:"amd64"`
+	NumCPU        int    `json:"numCPU" example:"4"`
+	Goroutines    int    `json:"goroutines" example:"12"`
+	HeapAlloc     uint64 `json:"heapAllocBytes" example:"4194304"`
+	HeapSys       uint64 `json:"heapSysBytes" example:"8388608"`
+	TotalAlloc    uint64 `json:"totalAllocBytes" example:"16777216"`
+	NumGC         uint32 `json:"numGC" example:"5"`
+	LastGCPauseNs uint64 `json:"lastGCPauseNs" example:"120000"`
+}
+
+// DBPoolStats mirrors database/sql pool statistics
+// @Description Database connection pool statistics
+type DBPoolStats struct {
+	MaxOpenConnections int   `json:"maxOpenConnections" example:"20"`
+	OpenConnections    int   `json:"openConnections" example:"3"`
+	InUse              int   `json:"inUse" example:"1"`
+	Idle               int   `json:"idle" example:"2"`
+	WaitCount          int64 `json:"waitCount" example:"0"`
+	WaitDurationMs     int64 `json:"waitDurationMs" example:"0"`
+	MaxIdleClosed      int64 `json:"maxIdleClosed" example:"0"`
+	MaxLifetimeClosed  int64 `json:"maxLifetimeClosed" example:"0"`
+}
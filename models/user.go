@@ -16,6 +16,11 @@ type User struct {
 
 // BeforeSave hook to hash password before saving to database
 func (u *User) BeforeSave(tx *gorm.DB) error {
+	return u.HashPassword()
+}
+
+// HashPassword replaces the plain text password with its bcrypt hash
+func (u *User) HashPassword() error {
 	if u.Password != "" {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
 		if err != nil {
@@ -0,0 +1,110 @@
+// repository/memory.go
+package repository
+
+import (
+	"context"
+	"listarr-backend/listing"
+	"listarr-backend/models"
+	"sync"
+)
+
+// MemoryUsers keeps users in memory, for tests that should not need a
+// database. It enforces the same unique emails and answers list requests
+// the same way as GormUsers.
+type MemoryUsers struct {
+	mu     sync.Mutex
+	users  map[uint]models.User
+	nextID uint
+}
+
+// NewMemoryUsers creates an empty in-memory user repository
+func NewMemoryUsers() *MemoryUsers {
+	return &MemoryUsers{users: map[uint]models.User{}, nextID: 1}
+}
+
+func (r *MemoryUsers) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.emailTaken(user.Email, 0) {
+		return ErrConflict
+	}
+	created := *user
+	if err := created.HashPassword(); err != nil {
+		return err
+	}
+	created.ID = r.nextID
+	r.nextID++
+	r.users[created.ID] = created
+	*user = created
+	return nil
+}
+
+func (r *MemoryUsers) Get(ctx context.Context, id uint) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *MemoryUsers) List(ctx context.Context, req listing.Request) (listing.Page[models.User], error) {
+	r.mu.Lock()
+	users := make([]models.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	r.mu.Unlock()
+
+	return listing.FindSlice(users, req)
+}
+
+func (r *MemoryUsers) Update(ctx context.Context, id uint, change func(user *models.User) error) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	if err := change(&user); err != nil {
+		return user, err
+	}
+	user.ID = id
+	if r.emailTaken(user.Email, id) {
+		return user, ErrConflict
+	}
+	if err := user.HashPassword(); err != nil {
+		return user, err
+	}
+	r.users[id] = user
+	return user, nil
+}
+
+func (r *MemoryUsers) Delete(ctx context.Context, id uint, check func(user models.User) error) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	if err := check(user); err != nil {
+		return user, err
+	}
+	delete(r.users, id)
+	return user, nil
+}
+
+// emailTaken reports whether a user other than except has email
+func (r *MemoryUsers) emailTaken(email string, except uint) bool {
+	for id, user := range r.users {
+		if id != except && user.Email == email {
+			return true
+		}
+	}
+	return false
+}
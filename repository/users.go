@@ -0,0 +1,100 @@
+// repository/users.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"listarr-backend/listing"
+	"listarr-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotFound reports that no record has the requested ID
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict reports a write violating a unique constraint, such as a
+	// second user with the same email
+	ErrConflict = errors.New("record conflicts with an existing one")
+)
+
+// Users stores users. Passwords are hashed on every write, and the users
+// returned carry the hash.
+type Users interface {
+	Create(ctx context.Context, user *models.User) error
+	Get(ctx context.Context, id uint) (models.User, error)
+	List(ctx context.Context, req listing.Request) (listing.Page[models.User], error)
+	// Update loads the user, lets change modify it and saves the result,
+	// with no other write to the user in between. An error from change
+	// aborts the update and is returned as is.
+	Update(ctx context.Context, id uint, change func(user *models.User) error) (models.User, error)
+	// Delete loads the user and removes it unless check returns an error,
+	// which is returned as is
+	Delete(ctx context.Context, id uint, check func(user models.User) error) (models.User, error)
+}
+
+// GormUsers stores users in the users table
+type GormUsers struct {
+	db *gorm.DB
+}
+
+// NewGormUsers creates a user repository on db
+func NewGormUsers(db *gorm.DB) *GormUsers {
+	return &GormUsers{db: db}
+}
+
+func (r *GormUsers) Create(ctx context.Context, user *models.User) error {
+	return translate(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r *GormUsers) Get(ctx context.Context, id uint) (models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).First(&user, id).Error
+	return user, translate(err)
+}
+
+func (r *GormUsers) List(ctx context.Context, req listing.Request) (listing.Page[models.User], error) {
+	return listing.Find[models.User](r.db.WithContext(ctx), req)
+}
+
+func (r *GormUsers) Update(ctx context.Context, id uint, change func(user *models.User) error) (models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error; err != nil {
+			return translate(err)
+		}
+		if err := change(&user); err != nil {
+			return err
+		}
+		// The ID names the user, whatever change did to it
+		user.ID = id
+		return translate(tx.Save(&user).Error)
+	})
+	return user, err
+}
+
+func (r *GormUsers) Delete(ctx context.Context, id uint, check func(user models.User) error) (models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error; err != nil {
+			return translate(err)
+		}
+		if err := check(user); err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	return user, err
+}
+
+// translate maps GORM errors to the errors of this package
+func translate(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrConflict
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"listarr-backend/database/dbtest"
+	"listarr-backend/listing"
+	"listarr-backend/models"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userListSpec = listing.Spec{
+	Fields: map[string]listing.Field{
+		"id":   {Column: "id", Sortable: true},
+		"name": {Column: "name", Sortable: true, Contains: true},
+	},
+	DefaultSort: "id",
+	KeyColumn:   "id",
+}
+
+// repos are the implementations every test runs against, so the in-memory
+// one stays interchangeable with the database
+var repos = map[string]func(t *testing.T) Users{
+	"memory": func(t *testing.T) Users {
+		return NewMemoryUsers()
+	},
+	"gorm": func(t *testing.T) Users {
+		return NewGormUsers(dbtest.OpenMigrated(t))
+	},
+}
+
+func forEachRepo(t *testing.T, test func(t *testing.T, users Users)) {
+	for name, newRepo := range repos {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+func TestUsers_CreateAndGet(t *testing.T) {
+	forEachRepo(t, func(t *testing.T, users Users) {
+		ctx := context.Background()
+		alice := models.User{Name: "Alice", Email: "alice@example.com", Password: "password123"}
+		require.NoError(t, users.Create(ctx, &alice))
+		assert.Equal(t, uint(1), alice.ID)
+		assert.NotEqual(t, "password123", alice.Password)
+
+		got, err := users.Get(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, alice, got)
+
+		_, err = users.Get(ctx, 2)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		err = users.Create(ctx, &models.User{Name: "Other", Email: "alice@example.com", Password: "password123"})
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+}
+
+func TestUsers_List(t *testing.T) {
+	forEachRepo(t, func(t *testing.T, users Users) {
+		ctx := context.Background()
+		for _, name := range []string{"Carol", "alice", "Bob", "Alan"} {
+			require.NoError(t, users.Create(ctx, &models.User{Name: name, Email: name + "@example.com", Password: "password123"}))
+		}
+
+		query, _ := url.ParseQuery("sort=-name&limit=2&name~=a")
+		req, err := listing.Parse(query, userListSpec, 100)
+		require.NoError(t, err)
+		page, err := users.List(ctx, req)
+		require.NoError(t, err)
+
+		var names []string
+		for _, user := range page.Items {
+			names = append(names, user.Name)
+		}
+		assert.Equal(t, []string{"alice", "Carol"}, names)
+		assert.Equal(t, int64(3), page.Total)
+		assert.True(t, page.HasMore)
+	})
+}
+
+func TestUsers_Update(t *testing.T) {
+	forEachRepo(t, func(t *testing.T, users Users) {
+		ctx := context.Background()
+		alice := models.User{Name: "Alice", Email: "alice@example.com", Password: "password123"}
+		require.NoError(t, users.Create(ctx, &alice))
+		bob := models.User{Name: "Bob", Email: "bob@example.com", Password: "password123"}
+		require.NoError(t, users.Create(ctx, &bob))
+
+		updated, err := users.Update(ctx, alice.ID, func(user *models.User) error {
+			assert.Equal(t, alice, *user)
+			user.ID = 9
+			user.Name = "Alice Smith"
+			user.Password = "password456"
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, alice.ID, updated.ID)
+		assert.NotEqual(t, "password456", updated.Password)
+		got, _ := users.Get(ctx, alice.ID)
+		assert.Equal(t, updated, got)
+
+		// An error from change leaves the user as it was
+		abort := errors.New("abort")
+		_, err = users.Update(ctx, alice.ID, func(user *models.User) error {
+			user.Name = "Changed"
+			return abort
+		})
+		assert.ErrorIs(t, err, abort)
+		got, _ = users.Get(ctx, alice.ID)
+		assert.Equal(t, "Alice Smith", got.Name)
+
+		_, err = users.Update(ctx, bob.ID, func(user *models.User) error {
+			user.Email = alice.Email
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrConflict)
+		got, _ = users.Get(ctx, bob.ID)
+		assert.Equal(t, bob, got)
+
+		_, err = users.Update(ctx, 7, func(user *models.User) error { return nil })
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestUsers_Delete(t *testing.T) {
+	forEachRepo(t, func(t *testing.T, users Users) {
+		ctx := context.Background()
+		alice := models.User{Name: "Alice", Email: "alice@example.com", Password: "password123"}
+		require.NoError(t, users.Create(ctx, &alice))
+
+		abort := errors.New("abort")
+		_, err := users.Delete(ctx, alice.ID, func(user models.User) error { return abort })
+		assert.ErrorIs(t, err, abort)
+		_, err = users.Get(ctx, alice.ID)
+		require.NoError(t, err)
+
+		deleted, err := users.Delete(ctx, alice.ID, func(user models.User) error { return nil })
+		require.NoError(t, err)
+		assert.Equal(t, alice, deleted)
+		_, err = users.Get(ctx, alice.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, err = users.Delete(ctx, alice.ID, func(user models.User) error { return nil })
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,84 @@
+// response/etag.go
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"listarr-backend/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag returns a strong entity tag for the JSON encoding of v, or an empty
+// string when v cannot be encoded
+func ETag(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// NotModified sets the ETag header and, when If-None-Match lists etag,
+// answers 304 and reports true so the handler can skip the body
+func NotModified(c *gin.Context, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	c.Header("ETag", etag)
+
+	header := c.GetHeader("If-None-Match")
+	if header == "" || !matchETag(header, etag, false) {
+		return false
+	}
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// CheckIfMatch compares If-Match with the current etag of the resource before
+// it is changed. It answers 412 when the resource changed since the client
+// read it, and 428 when the header is missing and required is set. When it
+// reports false the problem response has already been written.
+func CheckIfMatch(c *gin.Context, etag string, required bool) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if required {
+			Problem(c, http.StatusPreconditionRequired, models.CodePreconditionRequired,
+				"Send the ETag from a previous GET in the If-Match header")
+			return false
+		}
+		return true
+	}
+
+	if !matchETag(header, etag, true) {
+		Problem(c, http.StatusPreconditionFailed, models.CodePreconditionFailed,
+			"The resource was changed since it was read, fetch it again and retry")
+		return false
+	}
+	return true
+}
+
+// matchETag reports whether the If-Match or If-None-Match header value lists
+// etag. If-Match uses strong comparison, where weak tags never match.
+func matchETag(header, etag string, strong bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if strong {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
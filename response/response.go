@@ -0,0 +1,59 @@
+// response/response.go
+package response
+
+import (
+	"listarr-backend/baseurl"
+	"listarr-backend/logger"
+	"listarr-backend/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the media type of error responses
+const ProblemContentType = "application/problem+json"
+
+// requestIDHeader is set by the request ID middleware before handlers run
+const requestIDHeader = "X-Request-ID"
+
+// OK writes data in the success envelope with status 200
+func OK[T any](c *gin.Context, data T) {
+	c.JSON(http.StatusOK, models.APIResponse[T]{Success: true, Data: data})
+}
+
+// Created writes data in the success envelope with status 201
+func Created[T any](c *gin.Context, data T) {
+	c.JSON(http.StatusCreated, models.APIResponse[T]{Success: true, Data: data})
+}
+
+// List writes one page of items with its paging information
+func List[T any](c *gin.Context, items []T, meta models.PageMeta) {
+	c.JSON(http.StatusOK, models.APIResponse[[]T]{Success: true, Data: items, Meta: &meta})
+}
+
+// Problem aborts the request with an RFC 7807 problem
+func Problem(c *gin.Context, status int, code, detail string, fieldErrors ...models.FieldError) {
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(status, models.Problem{
+		Type:      models.ProblemTypePrefix + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  baseurl.Path(c, c.Request.URL.Path),
+		Code:      code,
+		RequestID: c.Writer.Header().Get(requestIDHeader),
+		Errors:    fieldErrors,
+	})
+}
+
+// NotFound aborts with a 404 not_found problem
+func NotFound(c *gin.Context, detail string) {
+	Problem(c, http.StatusNotFound, models.CodeNotFound, detail)
+}
+
+// Internal logs err and aborts with a 500 problem. The error itself is not
+// sent since it may describe internals such as queries.
+func Internal(c *gin.Context, err error) {
+	logger.FromContext(c.Request.Context()).Error("Request failed", "error", err)
+	Problem(c, http.StatusInternalServerError, models.CodeInternal, "An unexpected error occurred")
+}
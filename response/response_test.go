@@ -0,0 +1,165 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"listarr-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type signup struct {
+	Name    string `json:"name" binding:"required"`
+	Email   string `json:"email" binding:"required,email"`
+	Profile struct {
+		Theme string `json:"theme" binding:"oneof=light dark"`
+	} `json:"profile"`
+}
+
+func serve(t *testing.T, handler gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/signup", func(c *gin.Context) {
+		c.Header(requestIDHeader, "req-1")
+		handler(c)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/signup", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) models.Problem {
+	t.Helper()
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	return problem
+}
+
+func bind(c *gin.Context) {
+	var s signup
+	if err := c.ShouldBindJSON(&s); err != nil {
+		BindError(c, err)
+		return
+	}
+	Created(c, s.Name)
+}
+
+func TestOK(t *testing.T) {
+	w := serve(t, func(c *gin.Context) { OK(c, map[string]int{"id": 1}) }, "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"success":true,"data":{"id":1}}`, w.Body.String())
+}
+
+func TestList(t *testing.T) {
+	w := serve(t, func(c *gin.Context) {
+		List(c, []string{"a"}, models.PageMeta{Total: 3, Limit: 1, Offset: 2})
+	}, "")
+
+	assert.JSONEq(t, `{"success":true,"data":["a"],"meta":{"total":3,"limit":1,"offset":2}}`, w.Body.String())
+}
+
+func TestProblem(t *testing.T) {
+	w := serve(t, func(c *gin.Context) { NotFound(c, "User not found") }, "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, models.Problem{
+		Type:      "urn:listarr:problem:not_found",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Detail:    "User not found",
+		Instance:  "/signup",
+		Code:      models.CodeNotFound,
+		RequestID: "req-1",
+	}, decodeProblem(t, w))
+}
+
+func TestBindError(t *testing.T) {
+	w := serve(t, bind, `{"email":"nope","profile":{"theme":"blue"}}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, models.CodeValidationFailed, problem.Code)
+	assert.Equal(t, []models.FieldError{
+		{Field: "name", Rule: "required", Message: "is required"},
+		{Field: "email", Rule: "email", Message: "must be a valid email address"},
+		{Field: "profile.theme", Rule: "oneof", Message: "must be one of light, dark"},
+	}, problem.Errors)
+
+	for name, body := range map[string]string{"syntax": `{"name":`, "empty": ``} {
+		w := serve(t, bind, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		assert.Equal(t, models.CodeInvalidRequest, decodeProblem(t, w).Code, name)
+	}
+
+	w = serve(t, bind, `{"name":1}`)
+	problem = decodeProblem(t, w)
+	assert.Equal(t, models.CodeInvalidRequest, problem.Code)
+	assert.Equal(t, []models.FieldError{{Field: "name", Rule: "type", Message: "must be a string"}}, problem.Errors)
+
+	w = serve(t, bind, `{"name":"Ann","email":"ann@example.com","profile":{"theme":"dark"}}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"success":true,"data":"Ann"}`, w.Body.String())
+}
+
+func TestETagPreconditions(t *testing.T) {
+	etag := ETag(map[string]string{"name": "Ann"})
+	assert.Equal(t, etag, ETag(map[string]string{"name": "Ann"}))
+	assert.NotEqual(t, etag, ETag(map[string]string{"name": "Bob"}))
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.GET("/users/1", func(c *gin.Context) {
+			if NotModified(c, etag) {
+				return
+			}
+			OK(c, "Ann")
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/1", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, get("").Code)
+	assert.Equal(t, etag, get("").Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, get(`"old", W/`+etag).Code)
+	assert.Equal(t, http.StatusOK, get(`"old"`).Code)
+
+	put := func(ifMatch string, required bool) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.PUT("/users/1", func(c *gin.Context) {
+			if !CheckIfMatch(c, etag, required) {
+				return
+			}
+			OK(c, "Ann")
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/users/1", nil)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, put("", false).Code)
+	assert.Equal(t, http.StatusOK, put(etag, true).Code)
+	assert.Equal(t, http.StatusOK, put("*", true).Code)
+	assert.Equal(t, models.CodePreconditionRequired, decodeProblem(t, put("", true)).Code)
+	assert.Equal(t, models.CodePreconditionFailed, decodeProblem(t, put(`"old"`, false)).Code)
+	// If-Match uses strong comparison
+	assert.Equal(t, http.StatusPreconditionFailed, put("W/"+etag, false).Code)
+}
@@ -0,0 +1,96 @@
+// response/validation.go
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"listarr-backend/models"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report fields by their JSON names so details match the request body
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// BindError aborts with the problem matching an error from ShouldBindJSON:
+// validation_failed with the failing fields, or invalid_request for bodies
+// that are not valid JSON of the right shape
+func BindError(c *gin.Context, err error) {
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		fieldErrors := make([]models.FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			fieldErrors[i] = models.FieldError{Field: fieldPath(fe), Rule: fe.Tag(), Message: ruleMessage(fe)}
+		}
+		Problem(c, http.StatusBadRequest, models.CodeValidationFailed, "The request body failed validation", fieldErrors...)
+	case errors.Is(err, io.EOF):
+		Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, "The request body is empty")
+	case errors.As(err, &syntaxErr):
+		Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, fmt.Sprintf("The request body is not valid JSON at offset %d", syntaxErr.Offset))
+	case errors.As(err, &typeErr):
+		Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, "The request body is malformed",
+			models.FieldError{Field: typeErr.Field, Rule: "type", Message: "must be a " + typeErr.Type.String()})
+	default:
+		Problem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+	}
+}
+
+// fieldPath drops the struct name from the validator namespace, so
+// Configuration.app.name becomes app.name
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		return "is required when " + strings.Replace(fe.Param(), " ", " is ", 1)
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	}
+	return "failed the " + fe.Tag() + " rule"
+}
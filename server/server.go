@@ -0,0 +1,119 @@
+// server/server.go
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"listarr-backend/models"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Server runs the HTTP API and the background workers that live as long as it
+type Server struct {
+	httpServer      *http.Server
+	certs           *certReloader
+	shutdownTimeout time.Duration
+
+	workers sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// New builds a server for handler from the http section of cfg
+func New(handler http.Handler, cfg *models.Configuration) (*Server, error) {
+	s := &Server{
+		httpServer: &http.Server{
+			Addr:         ":" + cfg.HTTP.Port,
+			Handler:      handler,
+			ReadTimeout:  time.Duration(cfg.HTTP.ReadTimeout) * time.Second,
+			WriteTimeout: time.Duration(cfg.HTTP.WriteTimeout) * time.Second,
+			IdleTimeout:  time.Duration(cfg.HTTP.IdleTimeout) * time.Second,
+		},
+		shutdownTimeout: time.Duration(cfg.HTTP.ShutdownTimeout) * time.Second,
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	if cfg.HTTP.EnableSSL {
+		certs, err := newCertReloader(cfg.HTTP.SSLCert, cfg.HTTP.SSLKey)
+		if err != nil {
+			return nil, err
+		}
+		s.certs = certs
+		s.httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+	}
+
+	return s, nil
+}
+
+// Go runs fn as a background worker. fn must return once its context is
+// cancelled, which happens when the server shuts down.
+func (s *Server) Go(fn func(ctx context.Context)) {
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		fn(s.ctx)
+	}()
+}
+
+// Run serves requests until ctx is cancelled, then stops accepting
+// connections, drains in-flight requests and stops the background workers,
+// giving up after the configured shutdown timeout.
+func (s *Server) Run(ctx context.Context) error {
+	if s.certs != nil {
+		s.Go(s.certs.watch)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if s.httpServer.TLSConfig != nil {
+			slog.Info("Listening", "addr", s.httpServer.Addr, "tls", true)
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			slog.Info("Listening", "addr", s.httpServer.Addr, "tls", false)
+			err = s.httpServer.ListenAndServe()
+		}
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		s.cancel()
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for requests and workers to finish", "timeout", s.shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	s.cancel()
+	err := s.httpServer.Shutdown(shutdownCtx)
+
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		return errors.New("shutdown timed out before background workers stopped")
+	}
+
+	if err != nil {
+		return fmt.Errorf("error shutting down: %w", err)
+	}
+	return nil
+}
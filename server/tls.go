@@ -0,0 +1,92 @@
+// server/tls.go
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// certReloader serves the certificate at certFile/keyFile and reloads it
+// whenever either file changes, so renewed certificates apply without a restart
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("error loading TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// watch reloads the certificate on file changes until ctx is cancelled. The
+// directories are watched rather than the files so that atomic renames and
+// symlink swaps (as done by certbot and Kubernetes secrets) are noticed.
+func (r *certReloader) watch(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		slog.Error("Error watching TLS certificate", "error", err)
+		return
+	}
+	defer watcher.Close()
+
+	files := map[string]bool{}
+	for _, path := range []string{r.certFile, r.keyFile} {
+		path = filepath.Clean(path)
+		files[path] = true
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			slog.Error("Error watching TLS certificate directory", "dir", filepath.Dir(path), "error", err)
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-watcher.Errors:
+			slog.Error("TLS certificate watch error", "error", err)
+		case event := <-watcher.Events:
+			if !files[filepath.Clean(event.Name)] && !event.Has(fsnotify.Create) {
+				continue
+			}
+			// A failed reload usually means only one of the pair has been
+			// replaced so far; the current certificate stays in use
+			if err := r.reload(); err != nil {
+				slog.Warn("TLS certificate not reloaded", "error", err)
+				continue
+			}
+			slog.Info("TLS certificate reloaded")
+		}
+	}
+}
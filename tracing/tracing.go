@@ -0,0 +1,97 @@
+// tracing/tracing.go
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"listarr-backend/models"
+	"listarr-backend/version"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"gorm.io/gorm"
+	otelgorm "gorm.io/plugin/opentelemetry/tracing"
+)
+
+// Setup installs the global tracer provider described by the observability
+// section and W3C trace-context propagation. The returned function flushes
+// and stops the exporter. With tracing disabled spans are not recorded, but
+// incoming trace context is still passed on to outbound calls.
+func Setup(ctx context.Context, cfg *models.Configuration) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	obs := cfg.Observability
+	if !obs.TracingEnabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, obs.Exporter, obs.OTLPEndpoint, obs.OTLPInsecure)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(obs.ServiceName),
+		semconv.ServiceVersion(version.Version),
+		semconv.DeploymentEnvironment(cfg.App.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error building trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(obs.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, exporter, endpoint string, insecure bool) (sdktrace.SpanExporter, error) {
+	switch exporter {
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+	return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+}
+
+// untracedPaths are polled constantly by probes and scrapers and would drown
+// out useful traces
+var untracedPaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// Middleware starts a server span for each request, continuing the trace of
+// the caller when a traceparent header is present
+func Middleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return !untracedPaths[r.URL.Path]
+	}))
+}
+
+// InstrumentDB records a span for each GORM query run with a request context.
+// Query parameters are left out so user data and credentials stay out of traces.
+func InstrumentDB(db *gorm.DB, dbName string) error {
+	return db.Use(otelgorm.NewPlugin(
+		otelgorm.WithDBName(dbName),
+		otelgorm.WithoutQueryVariables(),
+		otelgorm.WithoutMetrics(),
+	))
+}
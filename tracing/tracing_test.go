@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"listarr-backend/integrations"
+	"listarr-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	cfg := &models.Configuration{}
+	shutdown, err := Setup(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+
+	cfg.Observability.TracingEnabled = true
+	cfg.Observability.Exporter = "stdout"
+	cfg.Observability.ServiceName = "listarr-test"
+	cfg.Observability.SampleRatio = 1
+	shutdown, err = Setup(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	cfg.Observability.Exporter = "zipkin"
+	_, err = Setup(context.Background(), cfg)
+	assert.Error(t, err)
+}
+
+func TestRequestSpansPropagateToIntegrations(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	_, err := Setup(context.Background(), &models.Configuration{})
+	require.NoError(t, err)
+
+	// The media server sees the trace context of the outbound call
+	var received string
+	media := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	defer media.Close()
+
+	client, err := integrations.NewClient(&models.Configuration{}, integrations.ClientOptions{})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware("listarr-test"))
+	r.GET("/api/v1/users", func(c *gin.Context) {
+		req, _ := http.NewRequestWithContext(c.Request.Context(), "GET", media.URL, nil)
+		resp, err := client.Do(req)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+		c.Status(http.StatusOK)
+	})
+	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/api/v1/users", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2, "probe requests are not traced")
+	for _, span := range spans {
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+	}
+	assert.Contains(t, received, traceID)
+}
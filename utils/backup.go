@@ -0,0 +1,153 @@
+// utils/backup.go
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BackupConfigFile returns app.config.json as stored, secrets still
+// encrypted, or without any secrets when excludeSecrets is set
+func BackupConfigFile(excludeSecrets bool) ([]byte, error) {
+	configLock.RLock()
+	data, err := os.ReadFile(configFilePath)
+	configLock.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+	if !excludeSecrets {
+		return data, nil
+	}
+
+	var configMap map[string]interface{}
+	if err := json.Unmarshal(data, &configMap); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %w", err)
+	}
+	StripSecrets(configMap)
+	return json.MarshalIndent(configMap, "", "  ")
+}
+
+// RestoreConfigFile replaces app.config.json with one from a backup and
+// reloads the configuration. The bootstrap sections of the current file are
+// kept, as they tell this instance how to reach its database, and so are its
+// secrets when keepSecrets is set for a backup made without them. The
+// previous file is put back when the restored one does not load.
+func RestoreConfigFile(data []byte, keepSecrets bool) error {
+	var restored map[string]interface{}
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return fmt.Errorf("error parsing config from backup: %w", err)
+	}
+
+	configLock.Lock()
+	previous, err := os.ReadFile(configFilePath)
+	if err != nil {
+		configLock.Unlock()
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	var current map[string]interface{}
+	if err := json.Unmarshal(previous, &current); err != nil {
+		configLock.Unlock()
+		return fmt.Errorf("error parsing config file: %w", err)
+	}
+
+	for _, section := range bootstrapSections {
+		if value, ok := current[section]; ok {
+			restored[section] = value
+		} else {
+			delete(restored, section)
+		}
+	}
+	if keepSecrets {
+		CopySecrets(restored, current)
+	}
+	if err := checkSecretsDecrypt(restored); err != nil {
+		configLock.Unlock()
+		return err
+	}
+
+	err = writeConfigFile(restored, masterKey)
+	configLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := ReloadConfig(); err != nil {
+		configLock.Lock()
+		writeErr := os.WriteFile(configFilePath, previous, 0600)
+		configLock.Unlock()
+		if writeErr != nil {
+			return errors.Join(err, fmt.Errorf("error putting back the previous config file: %w", writeErr))
+		}
+		return fmt.Errorf("config from backup does not load, kept the current one: %w", err)
+	}
+	return nil
+}
+
+// StripSecrets removes every secret from a nested config map
+func StripSecrets(configMap map[string]interface{}) {
+	for _, path := range SecretPaths() {
+		parent, key, ok := lookupParent(configMap, path)
+		if ok {
+			delete(parent, key)
+		}
+	}
+}
+
+// CopySecrets sets every secret of src missing from dst, two nested config
+// maps
+func CopySecrets(dst, src map[string]interface{}) {
+	for _, path := range SecretPaths() {
+		value, ok := lookupString(src, path)
+		if !ok {
+			continue
+		}
+		if _, ok := lookupString(dst, path); ok {
+			continue
+		}
+		if parent, key, ok := lookupParent(dst, path); ok {
+			parent[key] = value
+		}
+	}
+}
+
+// CheckSecretsDecrypt is checkSecretsDecrypt for callers not holding
+// configLock
+func CheckSecretsDecrypt(configMap map[string]interface{}) error {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return checkSecretsDecrypt(configMap)
+}
+
+// checkSecretsDecrypt returns an error when an encrypted secret in configMap
+// cannot be read with the current master key, as when the backup comes from
+// an instance with a different key
+func checkSecretsDecrypt(configMap map[string]interface{}) error {
+	for _, path := range SecretPaths() {
+		value, ok := lookupString(configMap, path)
+		if !ok || !IsEncrypted(value) {
+			continue
+		}
+		if _, err := DecryptSecret(masterKey, value); err != nil {
+			return fmt.Errorf("error decrypting %s from backup, was it made with a different master key? %w", path, err)
+		}
+	}
+	return nil
+}
+
+// lookupParent returns the map holding the last key of path, which must
+// exist up to the parent
+func lookupParent(configMap map[string]interface{}, path string) (map[string]interface{}, string, bool) {
+	parts := strings.Split(path, ".")
+	current := configMap
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			return nil, "", false
+		}
+		current = next
+	}
+	return current, parts[len(parts)-1], true
+}
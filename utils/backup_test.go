@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupAndRestoreConfigFile(t *testing.T) {
+	chdirTemp(t)
+	encodedKey, err := GenerateMasterKey()
+	require.NoError(t, err)
+	t.Setenv(MasterKeyEnv, encodedKey)
+	require.NoError(t, InitConfig())
+
+	cfg := *GetStoredConfig()
+	cfg.App.Name = "Before"
+	cfg.Integrations.Plex.Token = "plex-token"
+	require.NoError(t, SaveSettings(cfg))
+
+	full, err := BackupConfigFile(false)
+	require.NoError(t, err)
+	var fullMap map[string]interface{}
+	require.NoError(t, json.Unmarshal(full, &fullMap))
+	token, _ := lookupString(fullMap, "integrations.plex.token")
+	assert.True(t, IsEncrypted(token))
+
+	stripped, err := BackupConfigFile(true)
+	require.NoError(t, err)
+	var strippedMap map[string]interface{}
+	require.NoError(t, json.Unmarshal(stripped, &strippedMap))
+	_, ok := lookupString(strippedMap, "integrations.plex.token")
+	assert.False(t, ok)
+	host, _ := lookupString(strippedMap, "integrations.plex.host")
+	assert.Equal(t, "localhost", host)
+
+	cfg.App.Name = "After"
+	cfg.Integrations.Plex.Token = "new-token"
+	require.NoError(t, SaveSettings(cfg))
+
+	// Bootstrap sections stay those of this instance
+	strippedMap["db"].(map[string]interface{})["host"] = "backup-host"
+	stripped, err = json.Marshal(strippedMap)
+	require.NoError(t, err)
+
+	require.NoError(t, RestoreConfigFile(stripped, true))
+	assert.Equal(t, "Before", GetConfig().App.Name)
+	assert.Equal(t, "new-token", GetConfig().Integrations.Plex.Token)
+	assert.Equal(t, "localhost", GetConfig().Db.Host)
+
+	require.NoError(t, RestoreConfigFile(full, false))
+	assert.Equal(t, "plex-token", GetConfig().Integrations.Plex.Token)
+
+	// A config that does not load leaves the current one in place
+	fullMap["app"].(map[string]interface{})["environment"] = "production"
+	broken, err := json.Marshal(fullMap)
+	require.NoError(t, err)
+	assert.Error(t, RestoreConfigFile(broken, false))
+	assert.Equal(t, "development", GetConfig().App.Environment)
+	assert.Equal(t, "development", GetFileConfig().App.Environment)
+
+	// Secrets encrypted with another master key cannot be restored
+	foreign, err := EncryptSecret(testKey(t), "other-token")
+	require.NoError(t, err)
+	setString(fullMap, "integrations.plex.token", foreign)
+	fullMap["app"].(map[string]interface{})["environment"] = "development"
+	other, err := json.Marshal(fullMap)
+	require.NoError(t, err)
+	assert.ErrorContains(t, RestoreConfigFile(other, false), "different master key")
+	assert.Equal(t, "plex-token", GetConfig().Integrations.Plex.Token)
+}
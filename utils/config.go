@@ -2,13 +2,21 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"listarr-backend/events"
+	"listarr-backend/logger"
+	"listarr-backend/metrics"
 	"listarr-backend/models"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/knadh/koanf/parsers/dotenv"
 	kjson "github.com/knadh/koanf/parsers/json"
 	"github.com/knadh/koanf/providers/confmap"
@@ -17,97 +25,275 @@ import (
 	"github.com/knadh/koanf/v2"
 )
 
+const configFilePath = "config/app.config.json"
+
 var (
 	config     *models.Configuration
 	configLock sync.RWMutex
 	k          *koanf.Koanf
+	masterKey  []byte
+
+	// settingsStore holds the mutable settings, app.config.json by default
+	settingsStore SettingsStore = fileSettingsStore{}
+	watchOnce     sync.Once
 )
 
 func InitConfig() error {
-	k = koanf.New(".")
-
-	// 1. Load defaults
-	if err := k.Load(confmap.Provider(defaultConfig, "."), nil); err != nil {
-		return fmt.Errorf("error loading defaults: %w", err)
+	key, err := LoadMasterKey()
+	if err != nil {
+		return fmt.Errorf("error loading master key: %w", err)
 	}
 
+	// The file watcher reads masterKey while reloading, so it is only set
+	// with configLock held
+	configLock.Lock()
+	defer configLock.Unlock()
+	masterKey = key
+
 	if err := os.MkdirAll("./config", 0755); err != nil {
 		return fmt.Errorf("error creating config directory: %w", err)
 	}
 
-	// 2. Load app.config.json
-	f := file.Provider("config/app.config.json")
+	// Only create default config if file doesn't exist
+	if _, err := os.Stat(configFilePath); os.IsNotExist(err) {
+		if err := saveDefaultConfig(key); err != nil {
+			return fmt.Errorf("error saving default config: %w", err)
+		}
+	}
+
+	if err := loadConfig(); err != nil {
+		return err
+	}
+
+	slog.Debug("Configuration loaded", "environment", config.App.Environment, "settings", settingsStore.Name())
+
+	// app.config.json always holds the bootstrap values, so it is watched
+	// whichever settings store is active
+	watchOnce.Do(func() {
+		if err := watchConfigDir(context.Background(), filepath.Dir(configFilePath)); err != nil {
+			slog.Error("Error watching config directory", "error", err)
+		}
+	})
+
+	return nil
+}
+
+// watchConfigDir reloads the configuration whenever app.config.json or the
+// overlay of the current environment in dir changes, until ctx is cancelled.
+// The directory is watched rather than the files so that overlays created
+// after startup, or selected by a later change of app.environment, are
+// noticed as well.
+func watchConfigDir(ctx context.Context, dir string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return err
+	}
 
-	if err := k.Load(f, kjson.Parser()); err != nil {
-		// Only create default config if file doesn't exist
-		if os.IsNotExist(err) {
-			if err := saveDefaultConfig(); err != nil {
-				return fmt.Errorf("error saving default config: %w", err)
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-watcher.Errors:
+				slog.Error("Config directory watch error", "dir", dir, "error", err)
+			case event := <-watcher.Events:
+				if event.Has(fsnotify.Chmod) || !isConfigFile(event.Name) {
+					continue
+				}
+				// A removed app.config.json is about to be replaced, while a
+				// removed overlay no longer applies
+				if !event.Has(fsnotify.Create|fsnotify.Write) && filepath.Base(event.Name) == filepath.Base(configFilePath) {
+					continue
+				}
+				if err := ReloadConfig(); err != nil {
+					slog.Error("Error reloading config", "path", event.Name, "error", err)
+					continue
+				}
+				slog.Info("Configuration reloaded", "path", event.Name)
 			}
-		} else {
-			return fmt.Errorf("error loading config file: %w", err)
 		}
+	}()
+	return nil
+}
+
+// isConfigFile reports whether name is app.config.json or the overlay of the
+// environment currently in use
+func isConfigFile(name string) bool {
+	base := filepath.Base(name)
+	if base == filepath.Base(configFilePath) {
+		return true
+	}
+	overlay := overlayPath(GetConfig().App.Environment)
+	return overlay != "" && base == filepath.Base(overlay)
+}
+
+// loadConfig rebuilds k and config from every source. Callers must hold configLock.
+func loadConfig() error {
+	nk, err := buildConfig(settingsStore, true)
+	if err != nil {
+		return err
+	}
+
+	// Load the final config into a new struct so readers never see a partial update
+	cfg := &models.Configuration{}
+	if err := nk.UnmarshalWithConf("", cfg, koanf.UnmarshalConf{
+		Tag: "json", // Use json tags from your struct
+	}); err != nil {
+		return fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
-	fmt.Printf("After app.config.json - Database User: %s\n", k.String("db.user"))
+	if err := CheckProductionConfig(cfg); err != nil {
+		return err
+	}
+
+	k = nk
+	config = cfg
+	applyLogging(nk, cfg)
+	return nil
+}
 
-	f.Watch(func(event interface{}, err error) {
-		if err != nil {
-			fmt.Printf("watch error: %v\n", err)
-			return
+// applyLogging updates the logger with the level, format and secret values of
+// a newly loaded configuration
+func applyLogging(nk *koanf.Koanf, cfg *models.Configuration) {
+	var values []string
+	for _, path := range SecretPaths() {
+		if value := nk.String(path); value != "" {
+			values = append(values, value)
 		}
+	}
+	logger.SetSecrets(values)
+
+	if err := logger.SetFormat(cfg.App.LogFormat); err != nil {
+		slog.Warn("Keeping current log format", "error", err)
+	}
 
-		configLock.Lock()
-		defer configLock.Unlock()
+	previous := logger.Level()
+	if err := logger.SetLevel(cfg.App.LogLevel); err != nil {
+		slog.Warn("Keeping current log level", "error", err)
+	} else if logger.Level() != previous {
+		slog.Info("Log level changed", "level", cfg.App.LogLevel)
+	}
+}
 
-		// Create a new instance and reload
-		k = koanf.New(".")
+// buildConfig layers the configuration sources into a new koanf instance.
+// Environment variables are skipped when only the stored settings are wanted.
+func buildConfig(store SettingsStore, includeEnv bool) (*koanf.Koanf, error) {
+	// Stored settings are read first since they select the environment
+	stored := koanf.New(".")
+	if err := store.Load(stored); err != nil {
+		return nil, err
+	}
+	environment := resolveEnvironment(stored)
 
-		// Reload in the correct order
-		k.Load(confmap.Provider(defaultConfig, "."), nil)
-		k.Load(f, kjson.Parser())
-		k.Load(file.Provider(".env"), dotenv.Parser())
-		k.Load(env.Provider("LISTARR_", ".", envKeyReplacer), nil)
+	nk := koanf.New(".")
 
-		// Update the config struct
-		if err := k.Unmarshal("", config); err != nil {
-			fmt.Printf("error unmarshaling config: %v\n", err)
-			return
-		}
+	// 1. Load defaults, then the defaults for the current environment
+	if err := nk.Load(confmap.Provider(defaultConfig, "."), nil); err != nil {
+		return nil, fmt.Errorf("error loading defaults: %w", err)
+	}
+	if err := nk.Load(confmap.Provider(environmentDefaults[environment], "."), nil); err != nil {
+		return nil, fmt.Errorf("error loading %s defaults: %w", environment, err)
+	}
 
-		fmt.Println("Configuration reloaded due to file change")
-	})
+	// 2. Load stored settings, then app.config.<environment>.json
+	if err := nk.Merge(stored); err != nil {
+		return nil, fmt.Errorf("error merging settings: %w", err)
+	}
+	if err := loadOverlay(nk, environment); err != nil {
+		return nil, err
+	}
 
-	// 4. Load environment variables (highest priority)
-	fmt.Println("Loading environment variables")
-	if err := k.Load(env.Provider("LISTARR_", ".", func(s string) string {
-		return strings.ReplaceAll(strings.ToLower(
-			strings.TrimPrefix(s, "LISTARR_")), "_", ".")
-	}), nil); err != nil {
-		return fmt.Errorf("error loading environment variables: %w", err)
+	// 3. Decrypt secrets stored encrypted at rest
+	if err := decryptSecrets(masterKey, nk); err != nil {
+		return nil, err
 	}
 
-	// Load the final config into our struct
+	// 4. Load .env and environment variables (highest priority)
+	if includeEnv {
+		nk.Load(file.Provider(".env"), dotenv.Parser())
+		if err := nk.Load(env.Provider("LISTARR_", ".", envKeyReplacer), nil); err != nil {
+			return nil, fmt.Errorf("error loading environment variables: %w", err)
+		}
+	}
+
+	return nk, nil
+}
+
+// ReloadConfig re-reads the configuration from all sources
+func ReloadConfig() error {
 	configLock.Lock()
 	defer configLock.Unlock()
 
-	config = &models.Configuration{}
-	if err := k.UnmarshalWithConf("", config, koanf.UnmarshalConf{
-		Tag: "json", // Use json tags from your struct
-	}); err != nil {
-		return fmt.Errorf("error unmarshaling config: %w", err)
+	if err := loadConfig(); err != nil {
+		metrics.ConfigReloads.WithLabelValues("failure").Inc()
+		return err
 	}
-
+	metrics.ConfigReloads.WithLabelValues("success").Inc()
+	metrics.ConfigLastReload.SetToCurrentTime()
+	events.Publish(events.ConfigReloaded, events.Admins, nil)
 	return nil
 }
 
 func envKeyReplacer(s string) string {
-	return strings.ReplaceAll(
+	// The master key is never part of the configuration itself
+	if s == MasterKeyEnv || s == MasterKeyFileEnv {
+		return ""
+	}
+	key := strings.ReplaceAll(
 		strings.ToLower(
 			strings.TrimPrefix(s, "LISTARR_")),
 		"_",
 		".",
 	)
+	// Restore the casing of the json key so LISTARR_APP_APIBASEURL overrides
+	// app.apiBaseURL instead of sitting next to it
+	if canonical, ok := canonicalKeys()[key]; ok {
+		return canonical
+	}
+	return key
+}
+
+var (
+	configKeys     map[string]string
+	configKeysOnce sync.Once
+)
+
+// canonicalKeys maps lower-cased config paths to their json casing
+func canonicalKeys() map[string]string {
+	configKeysOnce.Do(func() {
+		configKeys = map[string]string{}
+		walkConfigFields(reflect.TypeOf(models.Configuration{}), "", func(path string, field reflect.StructField) {
+			configKeys[strings.ToLower(path)] = path
+		})
+	})
+	return configKeys
+}
+
+// walkConfigFields calls fn with the dotted json path of every leaf field of t
+func walkConfigFields(t reflect.Type, prefix string, fn func(path string, field reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			walkConfigFields(field.Type, path, fn)
+			continue
+		}
+		fn(path, field)
+	}
 }
 
 var defaultConfig = map[string]interface{}{
@@ -117,32 +303,51 @@ var defaultConfig = map[string]interface{}{
 	"app.appURL":      "http://localhost:3000",
 	"app.apiBaseURL":  "http://localhost:8080",
 	"app.logLevel":    "info",
+	"app.logFormat":   "text",
 	"app.maxPageSize": 100,
 
+	// Settings store defaults
+	"settings.backend": "file",
+
 	// Database defaults
-	"db.host":     "localhost",
-	"db.port":     "5432",
-	"db.name":     "listarr",
-	"db.user":     "postgres_user",
-	"db.password": "yourpassword",
-	"db.maxConns": 20,
-	"db.timeout":  30,
+	"db.driver":          "postgres",
+	"db.path":            "config/listarr.db",
+	"db.host":            "localhost",
+	"db.port":            "5432",
+	"db.name":            "listarr",
+	"db.user":            "postgres_user",
+	"db.password":        "yourpassword",
+	"db.sslMode":         "disable",
+	"db.sslRootCert":     "",
+	"db.maxConns":        20,
+	"db.maxIdleConns":    5,
+	"db.connMaxLifetime": 1800,
+	"db.connMaxIdleTime": 300,
+	"db.timeout":         30,
+	"db.startupTimeout":  60,
+	"db.migrateOnStart":  true,
 
 	// HTTP defaults
-	"http.port":             "8080",
-	"http.readTimeout":      30,
-	"http.writeTimeout":     30,
-	"http.idleTimeout":      60,
-	"http.enableSSL":        false,
-	"http.rateLimitEnabled": true,
-	"http.requestsPerMin":   100,
+	"http.port":               "8080",
+	"http.urlBase":            "",
+	"http.trustedProxies":     []string{"127.0.0.1", "::1"},
+	"http.readTimeout":        30,
+	"http.writeTimeout":       30,
+	"http.idleTimeout":        60,
+	"http.shutdownTimeout":    30,
+	"http.enableSSL":          false,
+	"http.rateLimitEnabled":   true,
+	"http.requestsPerMin":     100,
+	"http.requireIfMatch":     false,
+	"http.idempotencyWindow":  24,
+	"http.authRequestsPerMin": 10,
 
 	// Auth defaults
 	"auth.enableLocal":     true,
 	"auth.sessionTimeout":  60,
 	"auth.enable2FA":       false,
 	"auth.tokenExpiration": 24,
-	"auth.allowedOrigins":  []string{"http://localhost:3000"},
+	"auth.allowedOrigins":  []string{"http://localhost:3000", "http://localhost:5173"},
 
 	// Sync defaults
 	"sync.enabled":          true,
@@ -171,49 +376,118 @@ var defaultConfig = map[string]interface{}{
 	"spotdl.concurrentLimit":  2,
 	"spotdl.notifyOnComplete": true,
 
+	// Observability defaults
+	"observability.tracingEnabled": false,
+	"observability.exporter":       "otlp",
+	"observability.otlpEndpoint":   "localhost:4318",
+	"observability.otlpInsecure":   true,
+	"observability.serviceName":    "listarr-backend",
+	"observability.sampleRatio":    1.0,
+
+	// Frontend defaults
+	"frontend.enabled": true,
+	"frontend.dir":     "",
+
+	// Backup defaults
+	"backup.dir":            "config/backups",
+	"backup.interval":       24,
+	"backup.retention":      7,
+	"backup.excludeSecrets": false,
+
 	// Integrations defaults
 	"integrations.emby": map[string]interface{}{
-		"enabled": false,
-		"host":    "localhost",
-		"port":    8096,
-		"ssl":     false,
+		"enabled":         false,
+		"host":            "localhost",
+		"port":            8096,
+		"ssl":             false,
+		"bypassProxy":     false,
+		"ignoreTLSErrors": false,
+		"required":        false,
 	},
 	"integrations.jellyfin": map[string]interface{}{
-		"enabled": false,
-		"host":    "localhost",
-		"port":    8096,
-		"ssl":     false,
+		"enabled":         false,
+		"host":            "localhost",
+		"port":            8096,
+		"ssl":             false,
+		"bypassProxy":     false,
+		"ignoreTLSErrors": false,
+		"required":        false,
 	},
 	"integrations.plex": map[string]interface{}{
-		"enabled": false,
-		"host":    "localhost",
-		"port":    32400,
-		"ssl":     false,
+		"enabled":         false,
+		"host":            "localhost",
+		"port":            32400,
+		"ssl":             false,
+		"bypassProxy":     false,
+		"ignoreTLSErrors": false,
+		"required":        false,
 	},
 	"integrations.navidrome": map[string]interface{}{
-		"enabled": false,
-		"host":    "localhost",
-		"port":    4533,
-		"ssl":     false,
+		"enabled":         false,
+		"host":            "localhost",
+		"port":            4533,
+		"ssl":             false,
+		"bypassProxy":     false,
+		"ignoreTLSErrors": false,
+		"required":        false,
 	},
 	"integrations.spotify": map[string]interface{}{
-		"enabled": false,
-		"scopes":  "user-library-read playlist-read-private",
+		"enabled":     false,
+		"bypassProxy": false,
+		"scopes":      "user-library-read playlist-read-private",
 	},
 }
 
-func saveDefaultConfig() error {
+// saveDefaultConfig writes the default values to app.config.json, encrypting
+// secrets with key
+func saveDefaultConfig(key []byte) error {
 	if err := os.MkdirAll("./config", 0755); err != nil {
 		return fmt.Errorf("error creating config directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(k.Raw(), "", "  ")
+	dk := koanf.New(".")
+	if err := dk.Load(confmap.Provider(defaultConfig, "."), nil); err != nil {
+		return fmt.Errorf("error loading defaults: %w", err)
+	}
+
+	return writeConfigFile(dk.Raw(), key)
+}
+
+// writeConfigFile writes a nested config map to app.config.json, encrypting
+// secrets first when a master key is set
+func writeConfigFile(configMap map[string]interface{}, key []byte) error {
+	if key != nil {
+		if err := encryptSecrets(key, configMap); err != nil {
+			return err
+		}
+	}
+
+	return writeJSONFile(configFilePath, configMap)
+}
+
+// readJSONFile parses a config file such as app.config.json or an overlay
+func readJSONFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	var configMap map[string]interface{}
+	if err := json.Unmarshal(data, &configMap); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return configMap, nil
+}
+
+// writeJSONFile writes configMap to path as indented JSON
+func writeJSONFile(path string, configMap map[string]interface{}) error {
+	data, err := json.MarshalIndent(configMap, "", "  ")
 	if err != nil {
 		return fmt.Errorf("error marshaling config: %w", err)
 	}
 
-	if err := os.WriteFile("config/app.config.json", data, 0644); err != nil {
-		return fmt.Errorf("error writing config file: %w", err)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
 	}
 
 	return nil
@@ -246,7 +520,7 @@ func SaveConfig(cfg models.Configuration) error {
 	}
 
 	// Save to file
-	if err := saveDefaultConfig(); err != nil {
+	if err := writeConfigFile(k.Raw(), masterKey); err != nil {
 		return fmt.Errorf("error saving config: %w", err)
 	}
 
@@ -260,7 +534,10 @@ func GetFileConfig() *models.Configuration {
 	k := koanf.New(".")
 
 	// Load only the file configuration
-	if err := k.Load(file.Provider("config/app.config.json"), kjson.Parser()); err != nil {
+	if err := k.Load(file.Provider(configFilePath), kjson.Parser()); err != nil {
+		return nil
+	}
+	if err := decryptSecrets(currentMasterKey(), k); err != nil {
 		return nil
 	}
 
@@ -295,13 +572,8 @@ func SaveFileConfig(cfg models.Configuration) error {
 	}
 
 	// Write to file
-	data, err := json.MarshalIndent(fileK.Raw(), "", "  ")
-	if err != nil {
-		return fmt.Errorf("error marshaling config: %w", err)
-	}
-
-	if err := os.WriteFile("config/app.config.json", data, 0644); err != nil {
-		return fmt.Errorf("error writing config file: %w", err)
+	if err := writeConfigFile(fileK.Raw(), masterKey); err != nil {
+		return err
 	}
 
 	// The watch functionality will automatically reload the config
@@ -310,20 +582,9 @@ func SaveFileConfig(cfg models.Configuration) error {
 
 // ResetFileConfig resets app.config.json to default values
 func ResetFileConfig() error {
-	// Create a new koanf instance with just defaults
-	k := koanf.New(".")
-	if err := k.Load(confmap.Provider(defaultConfig, "."), nil); err != nil {
-		return fmt.Errorf("error loading defaults: %w", err)
-	}
-
 	// Save defaults to file
-	data, err := json.MarshalIndent(k.Raw(), "", "  ")
-	if err != nil {
-		return fmt.Errorf("error marshaling config: %w", err)
-	}
-
-	if err := os.WriteFile("config/app.config.json", data, 0644); err != nil {
-		return fmt.Errorf("error writing config file: %w", err)
+	if err := saveDefaultConfig(currentMasterKey()); err != nil {
+		return err
 	}
 
 	// Reload the main configuration
@@ -0,0 +1,103 @@
+// utils/environment.go
+package utils
+
+import (
+	"fmt"
+	"listarr-backend/models"
+	"log/slog"
+	"os"
+	"strings"
+
+	kjson "github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+// environments lists the accepted values of app.environment
+var environments = []string{"development", "staging", "production"}
+
+// environmentDefaults are applied over defaultConfig, beneath the stored
+// settings, for the matching app.environment
+var environmentDefaults = map[string]map[string]interface{}{
+	"production": {
+		"app.logLevel":          "warn",
+		"app.logFormat":         "json",
+		"auth.allowedOrigins":   []string{},
+		"http.rateLimitEnabled": true,
+	},
+}
+
+// resolveEnvironment returns the environment selected by LISTARR_APP_ENVIRONMENT
+// or the stored settings, falling back to the default
+func resolveEnvironment(stored *koanf.Koanf) string {
+	if environment := os.Getenv("LISTARR_APP_ENVIRONMENT"); environment != "" {
+		return environment
+	}
+	if environment := stored.String("app.environment"); environment != "" {
+		return environment
+	}
+	return defaultConfig["app.environment"].(string)
+}
+
+// overlayPath returns the overlay file for an environment, or "" when the
+// environment is not one of the accepted values
+func overlayPath(environment string) string {
+	for _, e := range environments {
+		if e == environment {
+			return fmt.Sprintf("config/app.config.%s.json", environment)
+		}
+	}
+	return ""
+}
+
+// loadOverlay merges app.config.<environment>.json into k when it exists
+func loadOverlay(k *koanf.Koanf, environment string) error {
+	path := overlayPath(environment)
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := k.Load(file.Provider(path), kjson.Parser()); err != nil {
+		return fmt.Errorf("error loading %s: %w", path, err)
+	}
+	return nil
+}
+
+// CheckProductionConfig refuses configurations that are unsafe to run in
+// production. Other environments are not checked.
+func CheckProductionConfig(cfg *models.Configuration) error {
+	if cfg.App.Environment != "production" {
+		return nil
+	}
+
+	var problems []string
+	if cfg.Db.Driver == "postgres" && (cfg.Db.Password == "" || cfg.Db.Password == "yourpassword") {
+		problems = append(problems, "db.password is empty or still set to the default")
+	}
+	if cfg.Auth.JWTSecret == "" || cfg.Auth.JWTSecret == "your-secret-key" {
+		problems = append(problems, "auth.jwtSecret is empty or still set to the example value")
+	}
+
+	for _, origin := range cfg.Auth.AllowedOrigins {
+		if origin == "*" {
+			problems = append(problems, "auth.allowedOrigins must list origins explicitly instead of *")
+		} else if strings.Contains(origin, "localhost") {
+			slog.Warn("auth.allowedOrigins contains a localhost origin in production", "origin", origin)
+		}
+	}
+
+	if cfg.HTTP.EnableSSL && (cfg.HTTP.SSLCert == "" || cfg.HTTP.SSLKey == "") {
+		problems = append(problems, "http.sslCert and http.sslKey are required when http.enableSSL is on")
+	}
+	if !cfg.HTTP.EnableSSL && !strings.HasPrefix(cfg.App.APIBaseURL, "https://") {
+		problems = append(problems, "enable http.enableSSL or set an https app.apiBaseURL served behind a TLS proxy")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("insecure production configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentOverlay(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, InitConfig())
+
+	overlay := `{"app": {"name": "Listarr Staging"}, "sync": {"enabled": false}}`
+	require.NoError(t, os.WriteFile("config/app.config.staging.json", []byte(overlay), 0600))
+
+	t.Setenv("LISTARR_APP_ENVIRONMENT", "staging")
+	require.NoError(t, ReloadConfig())
+
+	cfg := GetConfig()
+	assert.Equal(t, "staging", cfg.App.Environment)
+	assert.Equal(t, "Listarr Staging", cfg.App.Name)
+	assert.False(t, cfg.Sync.Enabled)
+	// Values missing from the overlay still come from the base file
+	assert.Equal(t, 100, cfg.App.MaxPageSize)
+}
+
+func TestProductionRefusesInsecureDefaults(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, InitConfig())
+
+	t.Setenv("LISTARR_APP_ENVIRONMENT", "production")
+	err := ReloadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "db.password")
+	assert.Contains(t, err.Error(), "auth.jwtSecret")
+
+	// The running configuration is kept when a reload is refused
+	assert.Equal(t, "development", GetConfig().App.Environment)
+
+	t.Setenv("LISTARR_DB_PASSWORD", "a-real-password")
+	t.Setenv("LISTARR_AUTH_JWTSECRET", "a-real-secret")
+	t.Setenv("LISTARR_APP_APIBASEURL", "https://listarr.example.com")
+	require.NoError(t, ReloadConfig())
+	assert.Equal(t, "production", GetConfig().App.Environment)
+}
+
+func TestEnvironmentOverlay_ReloadedWhenCreatedLater(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, InitConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	require.NoError(t, watchConfigDir(ctx, "config"))
+
+	// The staging overlay does not exist yet when the environment is selected
+	t.Setenv("LISTARR_APP_ENVIRONMENT", "staging")
+	require.NoError(t, ReloadConfig())
+	require.Equal(t, "staging", GetConfig().App.Environment)
+
+	overlay := `{"app": {"name": "Listarr Staging"}}`
+	require.NoError(t, os.WriteFile("config/app.config.staging.json", []byte(overlay), 0600))
+
+	assert.Eventually(t, func() bool {
+		return GetConfig().App.Name == "Listarr Staging"
+	}, 5*time.Second, 20*time.Millisecond)
+}
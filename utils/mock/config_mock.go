@@ -10,4 +10,7 @@ type MockConfigUtils interface {
 	GetFileConfig() *models.Configuration
 	SaveFileConfig(config models.Configuration) error
 	ResetFileConfig() error
+	GetStoredConfig() *models.Configuration
+	SaveSettings(config models.Configuration) error
+	ResetSettings() error
 }
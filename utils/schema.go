@@ -0,0 +1,216 @@
+// utils/schema.go
+package utils
+
+import (
+	"listarr-backend/models"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+)
+
+// JSONSchema is a JSON Schema (draft 2020-12) document or sub-schema
+type JSONSchema map[string]interface{}
+
+var (
+	configSchema     JSONSchema
+	configSchemaOnce sync.Once
+)
+
+// ConfigSchema returns the JSON Schema describing models.Configuration. It is
+// derived from the struct tags: json names, binding rules, examples,
+// descriptions and secret markers, with defaults taken from defaultConfig.
+func ConfigSchema() JSONSchema {
+	configSchemaOnce.Do(func() {
+		defaults := koanf.New(".")
+		defaults.Load(confmap.Provider(defaultConfig, "."), nil)
+
+		configSchema = objectSchema(reflect.TypeOf(models.Configuration{}), "", defaults)
+		configSchema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+		configSchema["title"] = "Listarr configuration"
+	})
+	return configSchema
+}
+
+func objectSchema(t reflect.Type, prefix string, defaults *koanf.Koanf) JSONSchema {
+	properties := JSONSchema{}
+	var required []string
+	// Fields required when another field holds a given value (required_if)
+	conditional := map[string][]string{}
+	jsonNames := map[string]string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		jsonNames[field.Name] = name
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		var schema JSONSchema
+		if field.Type.Kind() == reflect.Struct {
+			schema = objectSchema(field.Type, path, defaults)
+		} else {
+			schema = fieldSchema(field, path, defaults)
+		}
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			ruleName, param, _ := strings.Cut(rule, "=")
+			switch ruleName {
+			case "required":
+				required = append(required, name)
+			case "required_if":
+				conditional[param] = append(conditional[param], name)
+			default:
+				applyRule(schema, field.Type.Kind(), ruleName, param)
+			}
+		}
+
+		properties[name] = schema
+	}
+
+	schema := JSONSchema{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	var conditions []JSONSchema
+	for condition, fields := range conditional {
+		otherField, value, _ := strings.Cut(condition, " ")
+		otherName := jsonNames[otherField]
+		conditions = append(conditions, JSONSchema{
+			"if": JSONSchema{
+				"properties": JSONSchema{otherName: JSONSchema{"const": parseValue(properties[otherName].(JSONSchema)["type"], value)}},
+				"required":   []string{otherName},
+			},
+			"then": JSONSchema{"required": fields},
+		})
+	}
+	switch len(conditions) {
+	case 0:
+	case 1:
+		for key, value := range conditions[0] {
+			schema[key] = value
+		}
+	default:
+		schema["allOf"] = conditions
+	}
+
+	return schema
+}
+
+func fieldSchema(field reflect.StructField, path string, defaults *koanf.Koanf) JSONSchema {
+	schema := JSONSchema{}
+	itemType := ""
+
+	if field.Type.Kind() == reflect.Slice {
+		itemType = jsonType(field.Type.Elem().Kind())
+		schema["type"] = "array"
+		schema["items"] = JSONSchema{"type": itemType}
+	} else {
+		schema["type"] = jsonType(field.Type.Kind())
+	}
+
+	if example, ok := field.Tag.Lookup("example"); ok {
+		if itemType != "" {
+			var items []interface{}
+			for _, item := range strings.Split(example, ",") {
+				items = append(items, parseValue(itemType, item))
+			}
+			schema["examples"] = []interface{}{items}
+		} else {
+			schema["examples"] = []interface{}{parseValue(schema["type"], example)}
+		}
+	}
+
+	if defaults.Exists(path) {
+		schema["default"] = defaults.Get(path)
+	}
+
+	if field.Tag.Get("secret") == "true" {
+		schema["format"] = "password"
+		schema["x-secret"] = true
+	}
+
+	return schema
+}
+
+// applyRule translates a validator binding rule into JSON Schema keywords
+func applyRule(schema JSONSchema, kind reflect.Kind, rule, param string) {
+	switch rule {
+	case "oneof":
+		var values []interface{}
+		for _, value := range strings.Fields(param) {
+			values = append(values, parseValue(schema["type"], value))
+		}
+		schema["enum"] = values
+	case "min", "max":
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return
+		}
+		switch kind {
+		case reflect.String:
+			schema[rule+"Length"] = n
+		case reflect.Slice:
+			schema[rule+"Items"] = n
+		default:
+			schema[rule+"imum"] = n
+		}
+	case "url":
+		schema["format"] = "uri"
+	case "email":
+		schema["format"] = "email"
+	case "startswith":
+		schema["pattern"] = "^" + regexp.QuoteMeta(param)
+	}
+}
+
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Uint:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// parseValue converts a tag value to the Go type matching a JSON Schema type
+func parseValue(schemaType interface{}, value string) interface{} {
+	value = strings.TrimSpace(value)
+	switch schemaType {
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case "integer":
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
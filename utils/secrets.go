@@ -0,0 +1,334 @@
+// utils/secrets.go
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"listarr-backend/models"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	// MasterKeyEnv holds the base64 encoded master key used to encrypt secrets
+	MasterKeyEnv = "LISTARR_MASTER_KEY"
+	// MasterKeyFileEnv points to a file containing the base64 encoded master key
+	MasterKeyFileEnv = "LISTARR_MASTER_KEY_FILE"
+
+	// encryptedPrefix marks a config value as ciphertext produced by EncryptSecret
+	encryptedPrefix = "enc:v1:"
+	masterKeySize   = 32
+)
+
+// ErrMasterKeyMissing is returned when the config file holds encrypted values
+// but no master key has been configured.
+var ErrMasterKeyMissing = errors.New("config contains encrypted secrets but no master key is configured")
+
+// LoadMasterKey returns the master key from LISTARR_MASTER_KEY or the file named
+// by LISTARR_MASTER_KEY_FILE. A nil key means encryption at rest is disabled.
+func LoadMasterKey() ([]byte, error) {
+	if value := os.Getenv(MasterKeyEnv); value != "" {
+		return ParseMasterKey(value)
+	}
+
+	if path := os.Getenv(MasterKeyFileEnv); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading master key file: %w", err)
+		}
+		return ParseMasterKey(string(data))
+	}
+
+	return nil, nil
+}
+
+// ParseMasterKey decodes a base64 encoded 256-bit key
+func ParseMasterKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("master key is not valid base64: %w", err)
+	}
+	if len(key) != masterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", masterKeySize, len(key))
+	}
+	return key, nil
+}
+
+// GenerateMasterKey returns a new random key encoded for use in LISTARR_MASTER_KEY
+func GenerateMasterKey() (string, error) {
+	key := make([]byte, masterKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", fmt.Errorf("error generating master key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsEncrypted reports whether a config value was produced by EncryptSecret
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// EncryptSecret seals plaintext with AES-256-GCM. The nonce is stored in
+// front of the ciphertext so each value can be decrypted on its own.
+func EncryptSecret(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret opens a value produced by EncryptSecret. Plain values are
+// returned unchanged so existing config files keep working.
+func DecryptSecret(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if key == nil {
+		return "", ErrMasterKeyMissing
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("error decoding secret: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted secret is truncated")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("error decrypting secret: wrong master key or tampered value")
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// SecretPaths lists the dotted config keys of every field tagged secret:"true"
+func SecretPaths() []string {
+	var paths []string
+	walkConfigFields(reflect.TypeOf(models.Configuration{}), "", func(path string, field reflect.StructField) {
+		if field.Tag.Get("secret") == "true" {
+			paths = append(paths, path)
+		}
+	})
+	return paths
+}
+
+// encryptSecrets encrypts every non-empty secret in a nested config map in place
+func encryptSecrets(key []byte, configMap map[string]interface{}) error {
+	for _, path := range SecretPaths() {
+		value, ok := lookupString(configMap, path)
+		if !ok || value == "" || IsEncrypted(value) {
+			continue
+		}
+
+		encrypted, err := EncryptSecret(key, value)
+		if err != nil {
+			return fmt.Errorf("error encrypting %s: %w", path, err)
+		}
+		setString(configMap, path, encrypted)
+	}
+	return nil
+}
+
+// decryptSecrets replaces encrypted values loaded into k with their plaintext
+func decryptSecrets(key []byte, k *koanf.Koanf) error {
+	for _, path := range SecretPaths() {
+		value := k.String(path)
+		if !IsEncrypted(value) {
+			continue
+		}
+
+		plaintext, err := DecryptSecret(key, value)
+		if err != nil {
+			return fmt.Errorf("error decrypting %s: %w", path, err)
+		}
+		if err := k.Set(path, plaintext); err != nil {
+			return fmt.Errorf("error setting %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// currentMasterKey returns the master key for callers not holding configLock
+func currentMasterKey() []byte {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return masterKey
+}
+
+// RotateMasterKey re-encrypts every secret in app.config.json, the
+// environment overlays and, when db is not nil, the settings table with
+// newKey. oldKey may be nil when the secrets are currently plain text.
+// Everything is decrypted before anything is written, and the settings rows
+// are only committed once the files have been written.
+func RotateMasterKey(oldKey, newKey []byte, db *gorm.DB) error {
+	if newKey == nil {
+		return errors.New("new master key is required")
+	}
+
+	configLock.Lock()
+	defer configLock.Unlock()
+
+	configMap, err := readJSONFile(configFilePath)
+	if err != nil {
+		return err
+	}
+	// Plain text secrets of app.config.json are encrypted by writeConfigFile
+	if _, err := reencryptSecrets(configMap, oldKey, newKey); err != nil {
+		return fmt.Errorf("%s: %w", configFilePath, err)
+	}
+
+	overlays := map[string]map[string]interface{}{}
+	for _, environment := range environments {
+		path := overlayPath(environment)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
+		overlay, err := readJSONFile(path)
+		if err != nil {
+			return err
+		}
+		changed, err := reencryptSecrets(overlay, oldKey, newKey)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if changed {
+			overlays[path] = overlay
+		}
+	}
+
+	writeFiles := func() error {
+		if err := writeConfigFile(configMap, newKey); err != nil {
+			return err
+		}
+		for path, overlay := range overlays {
+			if err := writeJSONFile(path, overlay); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if db == nil {
+		return writeFiles()
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := reencryptSettings(tx, oldKey, newKey); err != nil {
+			return err
+		}
+		return writeFiles()
+	})
+}
+
+// reencryptSecrets replaces every encrypted secret in configMap by its value
+// encrypted with newKey and reports whether any value changed
+func reencryptSecrets(configMap map[string]interface{}, oldKey, newKey []byte) (bool, error) {
+	changed := false
+	for _, path := range SecretPaths() {
+		value, ok := lookupString(configMap, path)
+		if !ok || !IsEncrypted(value) {
+			continue
+		}
+
+		plaintext, err := DecryptSecret(oldKey, value)
+		if err != nil {
+			return false, fmt.Errorf("error decrypting %s: %w", path, err)
+		}
+		encrypted, err := EncryptSecret(newKey, plaintext)
+		if err != nil {
+			return false, fmt.Errorf("error encrypting %s: %w", path, err)
+		}
+		setString(configMap, path, encrypted)
+		changed = true
+	}
+	return changed, nil
+}
+
+// reencryptSettings re-encrypts the secrets held in the settings table
+func reencryptSettings(tx *gorm.DB, oldKey, newKey []byte) error {
+	var rows []models.Setting
+	if err := tx.Find(&rows).Error; err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+
+	for _, row := range rows {
+		var value interface{}
+		if err := json.Unmarshal([]byte(row.Value), &value); err != nil {
+			return fmt.Errorf("error parsing %s settings: %w", row.Section, err)
+		}
+
+		section := map[string]interface{}{row.Section: value}
+		changed, err := reencryptSecrets(section, oldKey, newKey)
+		if err != nil {
+			return fmt.Errorf("%s settings: %w", row.Section, err)
+		}
+		if !changed {
+			continue
+		}
+
+		data, err := json.Marshal(section[row.Section])
+		if err != nil {
+			return fmt.Errorf("error marshaling %s settings: %w", row.Section, err)
+		}
+		if err := tx.Model(&row).Update("value", string(data)).Error; err != nil {
+			return fmt.Errorf("error saving %s settings: %w", row.Section, err)
+		}
+	}
+	return nil
+}
+
+func lookupString(configMap map[string]interface{}, path string) (string, bool) {
+	parts := strings.Split(path, ".")
+	current := configMap
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		current = next
+	}
+	value, ok := current[parts[len(parts)-1]].(string)
+	return value, ok
+}
+
+func setString(configMap map[string]interface{}, path, value string) {
+	parts := strings.Split(path, ".")
+	current := configMap
+	for _, part := range parts[:len(parts)-1] {
+		current = current[part].(map[string]interface{})
+	}
+	current[parts[len(parts)-1]] = value
+}
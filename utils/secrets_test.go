@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(t *testing.T) []byte {
+	encoded, err := GenerateMasterKey()
+	require.NoError(t, err)
+	key, err := ParseMasterKey(encoded)
+	require.NoError(t, err)
+	return key
+}
+
+// chdirTemp runs the test from an empty directory so config/ writes are isolated
+func chdirTemp(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+	require.NoError(t, os.MkdirAll("config", 0755))
+}
+
+func TestEncryptDecryptSecret(t *testing.T) {
+	key := testKey(t)
+
+	encrypted, err := EncryptSecret(key, "plex-token")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, encrypted, "plex-token")
+
+	plaintext, err := DecryptSecret(key, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "plex-token", plaintext)
+
+	// Plain values pass through untouched
+	plaintext, err = DecryptSecret(key, "not-encrypted")
+	require.NoError(t, err)
+	assert.Equal(t, "not-encrypted", plaintext)
+}
+
+func TestDecryptSecret_WrongKey(t *testing.T) {
+	encrypted, err := EncryptSecret(testKey(t), "secret")
+	require.NoError(t, err)
+
+	_, err = DecryptSecret(testKey(t), encrypted)
+	assert.Error(t, err)
+
+	_, err = DecryptSecret(nil, encrypted)
+	assert.ErrorIs(t, err, ErrMasterKeyMissing)
+}
+
+func TestParseMasterKey_InvalidLength(t *testing.T) {
+	_, err := ParseMasterKey("c2hvcnQ=")
+	assert.Error(t, err)
+}
+
+func TestSecretPaths(t *testing.T) {
+	paths := SecretPaths()
+	assert.Contains(t, paths, "auth.jwtSecret")
+	assert.Contains(t, paths, "integrations.plex.token")
+	assert.Contains(t, paths, "integrations.spotify.clientSecret")
+	assert.NotContains(t, paths, "integrations.plex.host")
+}
+
+func TestRotateMasterKey(t *testing.T) {
+	chdirTemp(t)
+	oldKey, newKey := testKey(t), testKey(t)
+
+	configMap := map[string]interface{}{
+		"auth": map[string]interface{}{"jwtSecret": "jwt"},
+		"integrations": map[string]interface{}{
+			"plex": map[string]interface{}{"host": "localhost", "token": "plex-token"},
+		},
+	}
+	require.NoError(t, writeConfigFile(configMap, oldKey))
+	require.NoError(t, RotateMasterKey(oldKey, newKey, nil))
+
+	data, err := os.ReadFile(configFilePath)
+	require.NoError(t, err)
+
+	var rotated map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &rotated))
+
+	token, _ := lookupString(rotated, "integrations.plex.token")
+	host, _ := lookupString(rotated, "integrations.plex.host")
+	assert.True(t, IsEncrypted(token))
+	assert.Equal(t, "localhost", host)
+
+	_, err = DecryptSecret(oldKey, token)
+	assert.Error(t, err)
+
+	plaintext, err := DecryptSecret(newKey, token)
+	require.NoError(t, err)
+	assert.Equal(t, "plex-token", plaintext)
+}
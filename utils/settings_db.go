@@ -0,0 +1,285 @@
+// utils/settings_db.go
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"listarr-backend/models"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// settingsChannel is the Postgres NOTIFY channel used to announce setting changes
+const settingsChannel = "listarr_settings"
+
+// listenRetryDelay is how long to wait before re-establishing a lost listener
+const listenRetryDelay = 5 * time.Second
+
+// settingsPollInterval is how often databases without notifications are
+// checked for setting changes, replaced in tests
+var settingsPollInterval = 10 * time.Second
+
+// DatabaseSettingsStore keeps the mutable configuration sections in the
+// settings table so every instance sharing the database sees the same values
+type DatabaseSettingsStore struct {
+	db *gorm.DB
+}
+
+// NewDatabaseSettingsStore creates a settings store backed by db
+func NewDatabaseSettingsStore(db *gorm.DB) *DatabaseSettingsStore {
+	return &DatabaseSettingsStore{db: db}
+}
+
+func (s *DatabaseSettingsStore) Name() string {
+	return "database"
+}
+
+func (s *DatabaseSettingsStore) Load(k *koanf.Koanf) error {
+	// Bootstrap sections always come from app.config.json
+	fk := koanf.New(".")
+	if err := (fileSettingsStore{}).Load(fk); err != nil {
+		return err
+	}
+	for _, section := range bootstrapSections {
+		if fk.Exists(section) {
+			if err := k.MergeAt(fk.Cut(section), section); err != nil {
+				return fmt.Errorf("error loading %s settings: %w", section, err)
+			}
+		}
+	}
+
+	var rows []models.Setting
+	if err := s.db.Find(&rows).Error; err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+
+	sections := map[string]interface{}{}
+	for _, row := range rows {
+		if isBootstrapSection(row.Section) {
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(row.Value), &value); err != nil {
+			return fmt.Errorf("error parsing %s settings: %w", row.Section, err)
+		}
+		sections[row.Section] = value
+	}
+
+	if err := k.Load(confmap.Provider(sections, "."), nil); err != nil {
+		return fmt.Errorf("error loading settings: %w", err)
+	}
+	return nil
+}
+
+func (s *DatabaseSettingsStore) Save(cfg models.Configuration) error {
+	configMap, err := configToMap(cfg)
+	if err != nil {
+		return err
+	}
+	if key := currentMasterKey(); key != nil {
+		if err := encryptSecrets(key, configMap); err != nil {
+			return err
+		}
+	}
+
+	var rows []models.Setting
+	for section, value := range configMap {
+		if isBootstrapSection(section) {
+			continue
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("error marshaling %s settings: %w", section, err)
+		}
+		rows = append(rows, models.Setting{Section: section, Value: string(data)})
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error; err != nil {
+			return fmt.Errorf("error saving settings: %w", err)
+		}
+		return s.notify(tx)
+	})
+}
+
+func (s *DatabaseSettingsStore) Reset() error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Setting{}).Error; err != nil {
+			return fmt.Errorf("error resetting settings: %w", err)
+		}
+		return s.notify(tx)
+	})
+}
+
+// notify announces a change to other instances once tx commits
+func (s *DatabaseSettingsStore) notify(tx *gorm.DB) error {
+	if s.db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	if err := tx.Exec("SELECT pg_notify(?, ?)", settingsChannel, "changed").Error; err != nil {
+		return fmt.Errorf("error notifying settings change: %w", err)
+	}
+	return nil
+}
+
+// Watch calls onChange whenever any instance changes the stored settings and
+// blocks until ctx is cancelled. A lost listener is re-established and
+// followed by a reload in case notifications were missed. Databases other
+// than Postgres are polled instead.
+func (s *DatabaseSettingsStore) Watch(ctx context.Context, onChange func()) {
+	if s.db.Dialector.Name() != "postgres" {
+		s.poll(ctx, onChange)
+		return
+	}
+
+	for {
+		err := s.listen(ctx, onChange)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("Settings listener error", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+		onChange()
+	}
+}
+
+func (s *DatabaseSettingsStore) listen(ctx context.Context, onChange func()) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected database driver %T", driverConn)
+		}
+
+		pgxConn := stdConn.Conn()
+		if _, err := pgxConn.Exec(ctx, "LISTEN "+settingsChannel); err != nil {
+			return err
+		}
+		for {
+			if _, err := pgxConn.WaitForNotification(ctx); err != nil {
+				// Never hand a listening connection back to the pool
+				return errors.Join(driver.ErrBadConn, err)
+			}
+			onChange()
+		}
+	})
+}
+
+// poll calls onChange when the stored settings differ from the last check
+func (s *DatabaseSettingsStore) poll(ctx context.Context, onChange func()) {
+	last, err := s.fingerprint(ctx)
+	if err != nil {
+		slog.Error("Error reading settings", "error", err)
+	}
+
+	ticker := time.NewTicker(settingsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := s.fingerprint(ctx)
+		if err != nil {
+			slog.Error("Error reading settings", "error", err)
+			continue
+		}
+		if current != last {
+			last = current
+			onChange()
+		}
+	}
+}
+
+// fingerprint hashes the stored settings
+func (s *DatabaseSettingsStore) fingerprint(ctx context.Context) (string, error) {
+	var rows []models.Setting
+	if err := s.db.WithContext(ctx).Order("section").Find(&rows).Error; err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, row := range rows {
+		fmt.Fprintf(hash, "%s=%s\n", row.Section, row.Value)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// UseDatabaseSettings switches the active settings store to the database. On
+// first use the settings table is seeded with the current contents of
+// app.config.json. Run WatchSettings to pick up changes from other instances.
+func UseDatabaseSettings(db *gorm.DB) error {
+	store := NewDatabaseSettingsStore(db)
+
+	var count int64
+	if err := db.Model(&models.Setting{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("error counting settings: %w", err)
+	}
+	if count == 0 {
+		cfg, err := loadStoredConfig(fileSettingsStore{})
+		if err != nil {
+			return err
+		}
+		if err := store.Save(*cfg); err != nil {
+			return err
+		}
+		slog.Info("Seeded database settings from app.config.json")
+	}
+
+	configLock.Lock()
+	defer configLock.Unlock()
+
+	settingsStore = store
+	if err := loadConfig(); err != nil {
+		settingsStore = fileSettingsStore{}
+		return err
+	}
+	return nil
+}
+
+// WatchSettings reloads the configuration whenever another instance changes
+// the stored settings. It blocks until ctx is cancelled and returns at once
+// when the active store has no change notifications.
+func WatchSettings(ctx context.Context) {
+	store, ok := currentSettingsStore().(*DatabaseSettingsStore)
+	if !ok {
+		return
+	}
+
+	store.Watch(ctx, func() {
+		if err := ReloadConfig(); err != nil {
+			slog.Error("Error reloading config", "error", err)
+			return
+		}
+		slog.Info("Configuration reloaded due to settings change")
+	})
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"context"
+	"encoding/base64"
+	"listarr-backend/database/dbtest"
+	"listarr-backend/models"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseSettingsStore(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, InitConfig())
+	db := dbtest.OpenMigrated(t)
+
+	require.NoError(t, UseDatabaseSettings(db))
+	t.Cleanup(func() {
+		configLock.Lock()
+		settingsStore = fileSettingsStore{}
+		configLock.Unlock()
+	})
+	assert.Equal(t, "database", CurrentSettingsBackend())
+
+	// Seeded from app.config.json, without the bootstrap sections
+	var sections []string
+	require.NoError(t, db.Model(&models.Setting{}).Order("section").Pluck("section", &sections).Error)
+	assert.Contains(t, sections, "app")
+	assert.NotContains(t, sections, "db")
+
+	cfg := *GetStoredConfig()
+	cfg.App.Name = "Shared"
+	require.NoError(t, SaveSettings(cfg))
+	assert.Equal(t, "Shared", GetConfig().App.Name)
+
+	var app models.Setting
+	require.NoError(t, db.First(&app, "section = ?", "app").Error)
+	assert.Contains(t, app.Value, `"Shared"`)
+
+	require.NoError(t, ResetSettings())
+	assert.Equal(t, "Listarr", GetConfig().App.Name)
+}
+
+func TestDatabaseSettingsStore_PollsForChanges(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, InitConfig())
+	db := dbtest.OpenMigrated(t)
+	settingsPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { settingsPollInterval = 10 * time.Second })
+
+	changed := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewDatabaseSettingsStore(db).Watch(ctx, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// Another instance saves its settings
+	time.Sleep(3 * settingsPollInterval)
+	cfg := *GetConfig()
+	cfg.App.Name = "Elsewhere"
+	require.NoError(t, NewDatabaseSettingsStore(db).Save(cfg))
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("settings change was not noticed")
+	}
+}
+
+func TestRotateMasterKey_DatabaseSettings(t *testing.T) {
+	chdirTemp(t)
+	oldKey, newKey := testKey(t), testKey(t)
+	t.Setenv(MasterKeyEnv, base64.StdEncoding.EncodeToString(oldKey))
+	require.NoError(t, InitConfig())
+	db := dbtest.OpenMigrated(t)
+
+	require.NoError(t, UseDatabaseSettings(db))
+	t.Cleanup(func() {
+		configLock.Lock()
+		settingsStore = fileSettingsStore{}
+		configLock.Unlock()
+	})
+
+	cfg := *GetStoredConfig()
+	cfg.Integrations.Plex.Token = "plex-token"
+	require.NoError(t, SaveSettings(cfg))
+
+	encrypted, err := EncryptSecret(oldKey, "staging-secret")
+	require.NoError(t, err)
+	overlay := `{"auth": {"jwtSecret": "` + encrypted + `"}}`
+	require.NoError(t, os.WriteFile("config/app.config.staging.json", []byte(overlay), 0600))
+
+	require.NoError(t, RotateMasterKey(oldKey, newKey, db))
+
+	var integrations models.Setting
+	require.NoError(t, db.First(&integrations, "section = ?", "integrations").Error)
+	assert.NotContains(t, integrations.Value, "plex-token")
+
+	// Restart with the new key
+	t.Setenv(MasterKeyEnv, base64.StdEncoding.EncodeToString(newKey))
+	t.Setenv("LISTARR_APP_ENVIRONMENT", "staging")
+	require.NoError(t, InitConfig())
+	require.NoError(t, UseDatabaseSettings(db))
+
+	assert.Equal(t, "plex-token", GetConfig().Integrations.Plex.Token)
+	assert.Equal(t, "staging-secret", GetConfig().Auth.JWTSecret)
+}
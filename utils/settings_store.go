@@ -0,0 +1,164 @@
+// utils/settings_store.go
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"listarr-backend/models"
+
+	kjson "github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+// bootstrapSections are needed before the database is reachable, so they are
+// always read from app.config.json and the environment
+var bootstrapSections = []string{"settings", "db"}
+
+// SettingsStore persists the mutable configuration sections
+type SettingsStore interface {
+	// Name identifies the backend in logs and responses
+	Name() string
+	// Load merges the stored settings into k. Secrets are left encrypted.
+	Load(k *koanf.Koanf) error
+	// Save replaces the stored settings with cfg
+	Save(cfg models.Configuration) error
+	// Reset removes stored settings so the defaults apply again
+	Reset() error
+}
+
+// fileSettingsStore keeps every section in app.config.json
+type fileSettingsStore struct{}
+
+func (fileSettingsStore) Name() string {
+	return "file"
+}
+
+func (fileSettingsStore) Load(k *koanf.Koanf) error {
+	if err := k.Load(file.Provider(configFilePath), kjson.Parser()); err != nil {
+		return fmt.Errorf("error loading config file: %w", err)
+	}
+	return nil
+}
+
+func (fileSettingsStore) Save(cfg models.Configuration) error {
+	return SaveFileConfig(cfg)
+}
+
+func (fileSettingsStore) Reset() error {
+	return saveDefaultConfig(currentMasterKey())
+}
+
+// ValidateSettings checks cfg as it would apply once saved, layered with the
+// environment overlay and environment variables
+func ValidateSettings(cfg models.Configuration) error {
+	nk, err := buildConfig(pendingSettings{cfg: cfg}, true)
+	if err != nil {
+		return err
+	}
+
+	effective := &models.Configuration{}
+	if err := nk.UnmarshalWithConf("", effective, koanf.UnmarshalConf{Tag: "json"}); err != nil {
+		return fmt.Errorf("error unmarshaling config: %w", err)
+	}
+	return CheckProductionConfig(effective)
+}
+
+// pendingSettings is a read-only store over settings that are not saved yet
+type pendingSettings struct {
+	cfg models.Configuration
+}
+
+func (p pendingSettings) Name() string {
+	return "pending"
+}
+
+func (p pendingSettings) Load(k *koanf.Koanf) error {
+	configMap, err := configToMap(p.cfg)
+	if err != nil {
+		return err
+	}
+	return k.Load(confmap.Provider(configMap, "."), nil)
+}
+
+func (p pendingSettings) Save(cfg models.Configuration) error {
+	return fmt.Errorf("pending settings are read-only")
+}
+
+func (p pendingSettings) Reset() error {
+	return fmt.Errorf("pending settings are read-only")
+}
+
+// SaveSettings persists cfg to the active settings store and applies it
+func SaveSettings(cfg models.Configuration) error {
+	if err := currentSettingsStore().Save(cfg); err != nil {
+		return err
+	}
+	return ReloadConfig()
+}
+
+// ResetSettings restores the default settings in the active settings store
+func ResetSettings() error {
+	if err := currentSettingsStore().Reset(); err != nil {
+		return err
+	}
+	return ReloadConfig()
+}
+
+// GetStoredConfig returns the defaults merged with the stored settings and
+// environment overlay, without environment variable overrides
+func GetStoredConfig() *models.Configuration {
+	cfg, err := loadStoredConfig(currentSettingsStore())
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+func loadStoredConfig(store SettingsStore) (*models.Configuration, error) {
+	sk, err := buildConfig(store, false)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &models.Configuration{}
+	if err := sk.UnmarshalWithConf("", cfg, koanf.UnmarshalConf{Tag: "json"}); err != nil {
+		return nil, fmt.Errorf("error unmarshaling config: %w", err)
+	}
+	return cfg, nil
+}
+
+// CurrentSettingsBackend returns the name of the active settings store
+func CurrentSettingsBackend() string {
+	return currentSettingsStore().Name()
+}
+
+func currentSettingsStore() SettingsStore {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return settingsStore
+}
+
+func isBootstrapSection(section string) bool {
+	for _, s := range bootstrapSections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// configToMap converts cfg to the nested map shape used by koanf and the stores
+func configToMap(cfg models.Configuration) (map[string]interface{}, error) {
+	jsonBytes, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling config: %w", err)
+	}
+
+	var configMap map[string]interface{}
+	if err := json.Unmarshal(jsonBytes, &configMap); err != nil {
+		return nil, fmt.Errorf("error unmarshaling to map: %w", err)
+	}
+	return configMap, nil
+}
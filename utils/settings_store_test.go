@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSettingsStore_SaveAndReset(t *testing.T) {
+	chdirTemp(t)
+	encodedKey, err := GenerateMasterKey()
+	require.NoError(t, err)
+	t.Setenv(MasterKeyEnv, encodedKey)
+
+	require.NoError(t, InitConfig())
+	assert.Equal(t, "file", CurrentSettingsBackend())
+	assert.Equal(t, "Listarr", GetConfig().App.Name)
+
+	cfg := *GetStoredConfig()
+	cfg.App.Name = "Renamed"
+	cfg.Integrations.Plex.Token = "plex-token"
+	require.NoError(t, SaveSettings(cfg))
+
+	// Changes apply immediately and secrets never reach the disk in plain text
+	assert.Equal(t, "Renamed", GetConfig().App.Name)
+	assert.Equal(t, "plex-token", GetConfig().Integrations.Plex.Token)
+	data, err := os.ReadFile(configFilePath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "plex-token")
+
+	require.NoError(t, ResetSettings())
+	assert.Equal(t, "Listarr", GetConfig().App.Name)
+	assert.Empty(t, GetStoredConfig().Integrations.Plex.Token)
+}
@@ -0,0 +1,40 @@
+// version/version.go
+package version
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// Build metadata, set at build time with
+//
+//	go build -ldflags "-X listarr-backend/version.Version=1.2.0 -X listarr-backend/version.Commit=abc1234 -X listarr-backend/version.BuildDate=2024-01-01T00:00:00Z"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// StartTime is when the process started
+var StartTime = time.Now()
+
+// CommitHash returns Commit, falling back to the VCS revision the Go
+// toolchain records when the binary was built from a git checkout
+func CommitHash() string {
+	if Commit != "" {
+		return Commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+// Uptime returns how long the process has been running
+func Uptime() time.Duration {
+	return time.Since(StartTime)
+}
@@ -0,0 +1,26 @@
+// web/embed.go
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// dist holds the frontend build copied into web/dist before compiling, see
+// make frontend. It only contains .gitkeep in builds without a frontend.
+//
+//go:embed all:dist
+var dist embed.FS
+
+// Embedded returns the frontend build embedded in the binary, and false when
+// the binary was built without one
+func Embedded() (fs.FS, bool) {
+	sub, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil, false
+	}
+	if _, err := fs.Stat(sub, indexFile); err != nil {
+		return nil, false
+	}
+	return sub, true
+}
@@ -0,0 +1,240 @@
+// web/web.go
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"listarr-backend/baseurl"
+	"listarr-backend/models"
+	"listarr-backend/version"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	indexFile = "index.html"
+
+	// immutableCache lets browsers keep content-hashed assets for a year
+	immutableCache = "public, max-age=31536000, immutable"
+	// revalidateCache makes browsers check index.html and unhashed files on
+	// every load so new releases are picked up
+	revalidateCache = "no-cache"
+)
+
+// hashedAsset matches file names ending in a content hash, as produced by
+// Vite (index-BXk2j3aL.js) and webpack (main.3f2a9b1c.js, or longer hex)
+var hashedAsset = regexp.MustCompile(`[.-]([A-Za-z0-9_-]{8}|[0-9a-f]{16,})\.[A-Za-z0-9]+$`)
+
+// encodings are the precompressed variants looked up next to each file, in
+// order of preference
+var encodings = []struct{ name, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// isHashed reports whether name carries a content hash. Hashes also hold a
+// digit, which tells them from words as in apple-touch-icon.png.
+func isHashed(name string) bool {
+	match := hashedAsset.FindStringSubmatch(name)
+	return match != nil && strings.ContainsAny(match[1], "0123456789")
+}
+
+// Frontend serves a built single page application
+type Frontend struct {
+	files  fs.FS
+	config func() *models.Configuration
+}
+
+// New serves the frontend build in dir, or the one embedded in the binary
+// when dir is empty. It returns nil when there is nothing to serve.
+func New(dir string, config func() *models.Configuration) (*Frontend, error) {
+	if dir == "" {
+		files, ok := Embedded()
+		if !ok {
+			return nil, nil
+		}
+		return &Frontend{files: files, config: config}, nil
+	}
+
+	files := os.DirFS(dir)
+	if _, err := fs.Stat(files, indexFile); err != nil {
+		return nil, fmt.Errorf("frontend directory %s has no %s: %w", dir, indexFile, err)
+	}
+	return &Frontend{files: files, config: config}, nil
+}
+
+// Serve answers GET and HEAD requests outside the API with a file of the
+// frontend, or index.html for client side routes. It reports false when the
+// request is not for the frontend, leaving the response to the caller.
+func (f *Frontend) Serve(c *gin.Context) bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+	routePath, ok := baseurl.Strip(c)
+	if !ok {
+		return false
+	}
+	urlPath := path.Clean("/" + routePath)
+	if urlPath == "/api" || strings.HasPrefix(urlPath, "/api/") || strings.HasPrefix(urlPath, "/swagger/") {
+		return false
+	}
+
+	name := strings.TrimPrefix(urlPath, "/")
+	if name == "" || name == indexFile {
+		return f.serveIndex(c)
+	}
+
+	info, err := fs.Stat(f.files, name)
+	switch {
+	case err == nil && !info.IsDir():
+		return f.serveFile(c, name)
+	case err == nil || path.Ext(name) == "":
+		// History fallback: client side routes such as /lists/42 are
+		// rendered by the application
+		return f.serveIndex(c)
+	}
+	return false
+}
+
+// serveFile sends name, or its precompressed variant when the client
+// accepts it
+func (f *Frontend) serveFile(c *gin.Context, name string) bool {
+	if isHashed(path.Base(name)) {
+		c.Header("Cache-Control", immutableCache)
+	} else {
+		c.Header("Cache-Control", revalidateCache)
+	}
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+
+	served := name
+	varies := false
+	for _, encoding := range encodings {
+		if _, err := fs.Stat(f.files, name+encoding.ext); err != nil {
+			continue
+		}
+		varies = true
+		if served == name && acceptsEncoding(c.GetHeader("Accept-Encoding"), encoding.name) {
+			served = name + encoding.ext
+			c.Header("Content-Encoding", encoding.name)
+		}
+	}
+	if varies {
+		c.Header("Vary", "Accept-Encoding")
+	}
+
+	file, err := f.files.Open(served)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return false
+		}
+		content = bytes.NewReader(data)
+	}
+
+	http.ServeContent(c.Writer, c.Request, name, info.ModTime(), content)
+	c.Abort()
+	return true
+}
+
+// serveIndex sends index.html with the runtime configuration injected, so
+// one build works wherever the API is hosted
+func (f *Frontend) serveIndex(c *gin.Context) bool {
+	index, err := fs.ReadFile(f.files, indexFile)
+	if err != nil {
+		return false
+	}
+
+	page, err := injectConfig(index, f.runtimeConfig(baseurl.Base(c)))
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+
+	c.Header("Cache-Control", revalidateCache)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	c.Abort()
+	return true
+}
+
+// RuntimeConfig is exposed to the frontend as window.__LISTARR_CONFIG__
+type RuntimeConfig struct {
+	APIBaseURL string `json:"apiBaseURL"`
+	AppName    string `json:"appName"`
+	Version    string `json:"version"`
+	// URLBase is the path the application is served under, such as /listarr
+	URLBase string `json:"urlBase"`
+}
+
+func (f *Frontend) runtimeConfig(urlBase string) RuntimeConfig {
+	rc := RuntimeConfig{URLBase: urlBase, Version: version.Version}
+	if cfg := f.config(); cfg != nil {
+		rc.APIBaseURL = cfg.App.APIBaseURL
+		rc.AppName = cfg.App.Name
+	}
+	return rc
+}
+
+// injectConfig adds a script defining window.__LISTARR_CONFIG__ at the start
+// of the head element, or of documents without one, so it runs before the
+// application. Unless the document sets its own, a base element makes
+// relative asset URLs resolve against the URL base on nested client side
+// routes.
+func injectConfig(index []byte, rc RuntimeConfig) ([]byte, error) {
+	// json.Marshal escapes <, > and &, so values cannot close the script
+	data, err := json.Marshal(rc)
+	if err != nil {
+		return nil, err
+	}
+	snippet := "<script>window.__LISTARR_CONFIG__=" + string(data) + ";</script>"
+
+	lower := bytes.ToLower(index)
+	if !bytes.Contains(lower, []byte("<base")) {
+		snippet = `<base href="` + html.EscapeString(rc.URLBase) + `/">` + snippet
+	}
+
+	at := 0
+	if head := bytes.Index(lower, []byte("<head")); head >= 0 {
+		if end := bytes.IndexByte(lower[head:], '>'); end >= 0 {
+			at = head + end + 1
+		}
+	}
+	page := make([]byte, 0, len(index)+len(snippet))
+	page = append(page, index[:at]...)
+	page = append(page, snippet...)
+	return append(page, index[at:]...), nil
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows encoding
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(token), encoding) {
+			continue
+		}
+		q := strings.ReplaceAll(strings.TrimSpace(params), " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package web
+
+import (
+	"listarr-backend/baseurl"
+	"listarr-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(t *testing.T, urlBase string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &models.Configuration{}
+	cfg.App.Name = "Listarr"
+	cfg.App.APIBaseURL = "https://media.example.com/api"
+
+	frontend := &Frontend{
+		files: fstest.MapFS{
+			"index.html":                  {Data: []byte("<html><head><title>Listarr</title></head><body></body></html>")},
+			"favicon.ico":                 {Data: []byte("icon")},
+			"assets/index-BXk2j3aL.js":    {Data: []byte("console.log(1)")},
+			"assets/index-BXk2j3aL.js.br": {Data: []byte("brotli")},
+			"assets/index-BXk2j3aL.js.gz": {Data: []byte("gzip")},
+		},
+		config: func() *models.Configuration { return cfg },
+	}
+
+	forwarded, err := baseurl.Middleware(urlBase, nil)
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(forwarded)
+	r.NoRoute(func(c *gin.Context) {
+		if !frontend.Serve(c) {
+			c.Status(http.StatusNotFound)
+		}
+	})
+	return r
+}
+
+func get(r *gin.Engine, path, acceptEncoding string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestServe_IndexAndHistoryFallback(t *testing.T) {
+	r := newTestRouter(t, "")
+
+	for _, path := range []string{"/", "/index.html", "/lists/42", "/assets"} {
+		w := get(r, path, "")
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"), path)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html", path)
+		assert.Equal(t, `<html><head><base href="/"><script>window.__LISTARR_CONFIG__={"apiBaseURL":"https://media.example.com/api","appName":"Listarr","version":"dev","urlBase":""};</script><title>Listarr</title></head><body></body></html>`,
+			w.Body.String(), path)
+	}
+
+	// Missing files and API paths are left to the caller
+	assert.Equal(t, http.StatusNotFound, get(r, "/missing.js", "").Code)
+	assert.Equal(t, http.StatusNotFound, get(r, "/api/v1/nothing", "").Code)
+	assert.Equal(t, http.StatusNotFound, get(r, "/swagger/nothing", "").Code)
+}
+
+func TestServe_URLBase(t *testing.T) {
+	r := newTestRouter(t, "/listarr")
+
+	w := get(r, "/listarr/lists/42", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<base href="/listarr/">`)
+	assert.Contains(t, w.Body.String(), `"urlBase":"/listarr"`)
+
+	assert.Equal(t, http.StatusOK, get(r, "/listarr", "").Code)
+	assert.Equal(t, "icon", get(r, "/listarr/favicon.ico", "").Body.String())
+	assert.Equal(t, http.StatusNotFound, get(r, "/favicon.ico", "").Code)
+	assert.Equal(t, http.StatusNotFound, get(r, "/listarr/api/v1/nothing", "").Code)
+}
+
+func TestServe_AssetsAndEncodings(t *testing.T) {
+	r := newTestRouter(t, "")
+
+	w := get(r, "/favicon.ico", "")
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "icon", w.Body.String())
+
+	w = get(r, "/assets/index-BXk2j3aL.js", "gzip, deflate, br")
+	assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Equal(t, "brotli", w.Body.String())
+
+	w = get(r, "/assets/index-BXk2j3aL.js", "gzip, br;q=0")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "gzip", w.Body.String())
+
+	w = get(r, "/assets/index-BXk2j3aL.js", "")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "console.log(1)", w.Body.String())
+}
+
+func TestIsHashed(t *testing.T) {
+	for _, name := range []string{"index-BXk2j3aL.js", "main.3f2a9b1c.js", "vendor.8e0f6d2c4b1a9f3e7d5c.css"} {
+		assert.True(t, isHashed(name), name)
+	}
+	// Icons and other files kept under a fixed name must be revalidated
+	for _, name := range []string{"apple-touch-icon.png", "android-chrome-192x192.png", "mstile-150x150.png",
+		"favicon-32x32.png", "safari-pinned-tab.svg", "site.webmanifest", "index.html", "logo-darkmode.svg"} {
+		assert.False(t, isHashed(name), name)
+	}
+}
+
+func TestNew(t *testing.T) {
+	// Without a build in web/dist there is nothing to serve
+	if _, embedded := Embedded(); !embedded {
+		frontend, err := New("", nil)
+		assert.NoError(t, err)
+		assert.Nil(t, frontend)
+	}
+
+	_, err := New(t.TempDir(), nil)
+	assert.Error(t, err)
+}